@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/hub"
+	"github.com/tiredkangaroo/system/system"
+	"github.com/valyala/fasthttp"
+)
+
+// agentInit connects to the hub in agent.hub_url, if set, to serve its requests.
+func agentInit(app *fiber.App, infoService *system.SystemInfoService) {
+	c := conf().Agent
+	if c.HubURL == "" {
+		return
+	}
+	go hub.RunAgent(shuttingDown, hub.AgentOptions{
+		HubURL:       c.HubURL,
+		Name:         c.Name,
+		Token:        c.Token,
+		CAFile:       c.CAFile,
+		Insecure:     c.Insecure,
+		PushInterval: c.PushInterval.D(),
+		Info: func() (*system.SystemInfo, error) {
+			return infoService.GetSystemInfo(shuttingDown, system.AllFields)
+		},
+		Transport: appTransport{app},
+	})
+	slog.Info("agent mode enabled", "hub", c.HubURL, "name", c.Name, "role", c.Role)
+}
+
+// fromHub reports whether the request was sent by the hub over the connection of this agent.
+func fromHub(c *fiber.Ctx) bool {
+	tunneled, _ := c.Locals("hub_tunnel").(bool)
+	return tunneled
+}
+
+// appTransport serves the requests of the hub with the routes of app, without going
+// through a listener. requireAuthMiddleware authenticates them as the hub.
+type appTransport struct {
+	app *fiber.App
+}
+
+func (t appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var freq fasthttp.Request
+	freq.Header.SetMethod(req.Method)
+	freq.SetRequestURI(req.URL.RequestURI())
+	freq.Header.SetHost(req.Host)
+	for key, values := range req.Header {
+		for _, v := range values {
+			freq.Header.Add(key, v)
+		}
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		freq.SetBody(body)
+	}
+	remoteAddr, _ := net.ResolveTCPAddr("tcp", req.RemoteAddr)
+	if remoteAddr == nil {
+		remoteAddr = &net.TCPAddr{}
+	}
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&freq, remoteAddr, nil)
+	ctx.SetUserValue("hub_tunnel", true)
+	t.app.Handler()(&ctx)
+
+	resp := &http.Response{
+		Status:        http.StatusText(ctx.Response.StatusCode()),
+		StatusCode:    ctx.Response.StatusCode(),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		ContentLength: -1,
+		Request:       req,
+	}
+	ctx.Response.Header.VisitAll(func(key, value []byte) {
+		resp.Header.Add(string(key), string(value))
+	})
+	if stream := ctx.Response.BodyStream(); stream != nil {
+		// e.g. logs sent with sendReader, closing the stream stops journalctl
+		closer, ok := stream.(io.ReadCloser)
+		if !ok {
+			closer = io.NopCloser(stream)
+		}
+		resp.Body = closer
+	} else {
+		resp.Body = io.NopCloser(bytes.NewReader(bytes.Clone(ctx.Response.Body())))
+	}
+	return resp, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/audit"
+)
+
+var auditLogger *audit.Logger
+
+func auditInit() {
+	c := conf().Audit
+	if c.File == "" {
+		c.File = filepath.Join(stateDir(), "audit.log")
+	}
+	var err error
+	auditLogger, err = audit.NewLogger(audit.Options{
+		Path:       c.File,
+		MaxSize:    c.MaxSize,
+		MaxBackups: c.MaxBackups,
+		Journal:    c.Journal,
+	})
+	if err != nil {
+		slog.Warn("audit log disabled, set audit.file or state_dir to a writable location to enable", "error", err)
+		return
+	}
+	slog.Info("audit log enabled", "file", c.File, "journal", c.Journal)
+}
+
+// auditMiddleware records the outcome of the privileged action handled after it.
+// it must come before privilegeMiddleware so that denied attempts are recorded too.
+func auditMiddleware(c *fiber.Ctx) error {
+	if auditLogger == nil {
+		return c.Next()
+	}
+	params := auditParams(c)
+	err := c.Next()
+
+	entry := auditEntry(c, params)
+	entry.Status = c.Response().StatusCode()
+	if err != nil {
+		entry.Status = fiber.StatusInternalServerError
+		entry.Error = err.Error()
+	} else if entry.Status >= 400 {
+		var resp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(c.Response().Body(), &resp) == nil {
+			entry.Error = resp.Error
+		}
+	}
+	logAudit(entry)
+	return err
+}
+
+// auditAttempt records that the action handled after it is about to run, for actions such
+// as a shutdown that may never return to auditMiddleware. it comes right before the
+// handler, so that only attempts that passed every check are recorded.
+func auditAttempt(c *fiber.Ctx) error {
+	if auditLogger != nil {
+		entry := auditEntry(c, auditParams(c))
+		entry.Attempt = true
+		logAudit(entry)
+	}
+	return c.Next()
+}
+
+// auditEntry returns the entry of the request, without its result.
+func auditEntry(c *fiber.Ctx, params map[string]string) audit.Entry {
+	return audit.Entry{
+		Time:     time.Now(),
+		ClientIP: c.IP(),
+		Identity: identity(c),
+		Method:   c.Method(),
+		Route:    c.Route().Path,
+		Target:   auditTarget(c),
+		Params:   params,
+	}
+}
+
+func logAudit(entry audit.Entry) {
+	if err := auditLogger.Log(entry); err != nil {
+		slog.Error("write audit log", "error", err)
+	}
+}
+
+func auditTarget(c *fiber.Ctx) string {
+	for _, key := range []string{"pid", "name", "id", "action"} {
+		if v := c.Params(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// auditParams collects the route parameters and JSON body fields of the request.
+func auditParams(c *fiber.Ctx) map[string]string {
+	params := c.AllParams()
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		var body map[string]any
+		if json.Unmarshal(c.Body(), &body) == nil {
+			for k, v := range body {
+				if strings.Contains(k, "secret") || strings.Contains(k, "code") {
+					v = "[redacted]"
+				}
+				data, _ := json.Marshal(v)
+				params[k] = strings.Trim(string(data), `"`)
+			}
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalIdentifier is the SYSLOG_IDENTIFIER used when audit entries are mirrored to the journal.
+const JournalIdentifier = "system-audit"
+
+type Entry struct {
+	Time     time.Time         `json:"time"`
+	ClientIP string            `json:"client_ip"`
+	Identity string            `json:"identity"`         // authenticated identity that performed the action
+	Method   string            `json:"method"`           // http method, or GRPC
+	Route    string            `json:"route"`            // route pattern, e.g: /api/v1/service/:name/restart, or the full gRPC method
+	Target   string            `json:"target,omitempty"` // pid, service name, etc.
+	Params   map[string]string `json:"params,omitempty"`
+	Status   int               `json:"status"`          // http status of the response, 0 for attempts
+	Error    string            `json:"error,omitempty"` // error returned by the action, if any
+
+	// Attempt is set on the entry recorded before running an action that may never return
+	// (e.g: a reboot), which is followed by the entry with the result if it does.
+	Attempt bool `json:"attempt,omitempty"`
+}
+
+type Options struct {
+	Path       string // path of the audit log file
+	MaxSize    int64  // size in bytes after which the file is rotated
+	MaxBackups int    // number of rotated files kept (path.1, path.2, ...)
+	Journal    bool   // also send every entry to the systemd journal
+}
+
+// Logger is an append-only audit log of JSON lines, rotated by size.
+type Logger struct {
+	opts Options
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (l *Logger) Log(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.opts.MaxSize > 0 && l.size+int64(len(data)) > l.opts.MaxSize {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("rotate audit log: %w", err)
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if l.opts.Journal {
+		if err := sendToJournal(e); err != nil {
+			slog.Error("mirror audit entry to journal", "error", err)
+		}
+	}
+	return nil
+}
+
+type Filter struct {
+	Since    *time.Time
+	Until    *time.Time
+	Identity string
+	Route    string
+	Limit    int // most recent entries to return, 0 means all
+}
+
+func (f Filter) matches(e Entry) bool {
+	if f.Since != nil && e.Time.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && e.Time.After(*f.Until) {
+		return false
+	}
+	if f.Identity != "" && e.Identity != f.Identity {
+		return false
+	}
+	if f.Route != "" && e.Route != f.Route {
+		return false
+	}
+	return true
+}
+
+// Query returns the entries matching filter in chronological order, including those
+// in rotated files.
+func (l *Logger) Query(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []Entry
+	for i := l.opts.MaxBackups; i >= 0; i-- {
+		path := l.opts.Path
+		if i > 0 {
+			path = backupPath(path, i)
+		}
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var e Entry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue // partially written line
+			}
+			if filter.matches(e) {
+				entries = append(entries, e)
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if l.opts.MaxBackups > 0 {
+		for i := l.opts.MaxBackups - 1; i >= 1; i-- {
+			err := os.Rename(backupPath(l.opts.Path, i), backupPath(l.opts.Path, i+1))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		if err := os.Rename(l.opts.Path, backupPath(l.opts.Path, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.opts.Path); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func (l *Logger) open() error {
+	f, err := os.OpenFile(l.opts.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = stat.Size()
+	return nil
+}
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+func NewLogger(opts Options) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o700); err != nil {
+		return nil, err
+	}
+	l := &Logger{opts: opts}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
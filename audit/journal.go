@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// sendToJournal writes e to the systemd journal using its native protocol, with structured
+// AUDIT_* fields so entries can be filtered with e.g: journalctl SYSLOG_IDENTIFIER=system-audit AUDIT_IDENTITY=alice
+func sendToJournal(e Entry) error {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	priority := "5" // notice
+	message := fmt.Sprintf("%s %s %s by %s from %s: %d", e.Method, e.Route, e.Target, e.Identity, e.ClientIP, e.Status)
+	attempt := ""
+	if e.Attempt {
+		message = fmt.Sprintf("%s %s %s by %s from %s: attempted", e.Method, e.Route, e.Target, e.Identity, e.ClientIP)
+		attempt = "1"
+	}
+	if e.Error != "" {
+		priority = "4" // warning
+		message += " (" + e.Error + ")"
+	}
+	fields := [][2]string{
+		{"MESSAGE", message},
+		{"PRIORITY", priority},
+		{"SYSLOG_IDENTIFIER", JournalIdentifier},
+		{"AUDIT_CLIENT_IP", e.ClientIP},
+		{"AUDIT_IDENTITY", e.Identity},
+		{"AUDIT_METHOD", e.Method},
+		{"AUDIT_ROUTE", e.Route},
+		{"AUDIT_TARGET", e.Target},
+		{"AUDIT_STATUS", strconv.Itoa(e.Status)},
+		{"AUDIT_ERROR", e.Error},
+		{"AUDIT_ATTEMPT", attempt},
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		b.WriteString(f[0])
+		b.WriteByte('=')
+		b.WriteString(strings.ReplaceAll(f[1], "\n", " "))
+		b.WriteByte('\n')
+	}
+	_, err = conn.Write([]byte(b.String()))
+	return err
+}
@@ -1,63 +1,236 @@
 package main
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/pquerna/otp"
-	"github.com/pquerna/otp/totp"
+	"github.com/tiredkangaroo/system/auth"
 )
 
-var totpSecret = os.Getenv("SYSTEM_TOTP_SECRET")
-var jwtSecret = os.Getenv("SYSTEM_JWT_SECRET")
+// legacyUserName is the name of the admin user whose secret is SYSTEM_TOTP_SECRET.
+// a stored user with the same name takes precedence over it.
+const legacyUserName = "admin"
 
-func authInit() {
-	if len(totpSecret) >= 32 && len(jwtSecret) >= 32 {
-		slog.Info("authentication enabled")
+var users *auth.UserStore
+var tokens *auth.TokenStore
+var sessions *auth.SessionStore
+
+var authLimiter = auth.NewLimiter(auth.DefaultLimiterOptions)
+var totpReplayGuard = auth.NewReplayGuard()
+
+// securityLog is used for security events (failed logins, lockouts, replays) so they
+// can be told apart from other logs by their event attribute.
+var securityLog = slog.Default().With("component", "security")
+
+type authClaims struct {
+	Role auth.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func authInit() error {
+	usersFile := conf().Auth.UsersFile
+	if usersFile == "" {
+		usersFile = filepath.Join(stateDir(), "users.json")
+	}
+	var err error
+	users, err = auth.NewUserStore(usersFile)
+	if err != nil {
+		return fmt.Errorf("load users from %s: %w", usersFile, err)
+	}
+	tokensFile := conf().Auth.TokensFile
+	if tokensFile == "" {
+		tokensFile = filepath.Join(stateDir(), "tokens.json")
+	}
+	tokens, err = auth.NewTokenStore(tokensFile)
+	if err != nil {
+		return fmt.Errorf("load api tokens from %s: %w", tokensFile, err)
+	}
+	sessionsFile := conf().Auth.SessionsFile
+	if sessionsFile == "" {
+		sessionsFile = filepath.Join(stateDir(), "sessions.json")
+	}
+	sessions, err = auth.NewSessionStore(sessionsFile)
+	if err != nil {
+		return fmt.Errorf("load sessions from %s: %w", sessionsFile, err)
+	}
+	if authEnabled() {
+		slog.Info("authentication enabled", "users", users.Len(), "legacy_admin", conf().Auth.TOTPSecret != "", "client_certs", len(conf().TLS.ClientCerts), "local_users", len(conf().Auth.LocalUsers))
 	} else {
-		slog.Warn("authentication disabled, set SYSTEM_TOTP_SECRET and SYSTEM_JWT_SECRET env vars where both variables have a length of at least 32 characters to enable")
-		totpSecret = ""
-		jwtSecret = ""
+		slog.Warn("authentication disabled, set auth.jwt_secret (or SYSTEM_JWT_SECRET) and either set auth.totp_secret (or SYSTEM_TOTP_SECRET) or create users to enable")
+	}
+	return nil
+}
+
+// authEnabled reports whether requests need to be authenticated. it becomes true as soon
+// as the first user is created if a JWT secret is configured.
+func authEnabled() bool {
+	return loginEnabled() || len(conf().TLS.ClientCerts) > 0 || len(conf().Auth.LocalUsers) > 0
+}
+
+// loginEnabled reports whether users can log in with TOTP codes.
+func loginEnabled() bool {
+	return conf().Auth.JWTSecret != "" && (conf().Auth.TOTPSecret != "" || users.Len() > 0)
+}
+
+func lookupUser(name string) (auth.User, error) {
+	totpSecret := conf().Auth.TOTPSecret
+	u, err := users.Get(name)
+	if errors.Is(err, auth.ErrUserNotFound) && name == legacyUserName && totpSecret != "" {
+		return auth.User{Name: legacyUserName, Role: auth.RoleAdmin, TOTPSecret: totpSecret}, nil
+	}
+	return u, err
+}
+
+// storeLegacyUser adds the legacy admin user to the user store, so that its secret can be
+// rotated like the secret of any other user. it does nothing for any other user.
+func storeLegacyUser(name string) error {
+	totpSecret := conf().Auth.TOTPSecret
+	if name != legacyUserName || totpSecret == "" {
+		return nil
+	}
+	if _, err := users.Get(name); !errors.Is(err, auth.ErrUserNotFound) {
+		return err
+	}
+	return users.Create(auth.User{Name: legacyUserName, Role: auth.RoleAdmin, TOTPSecret: totpSecret})
+}
+
+// totpIssuer is shown by authenticator apps next to the user name.
+func totpIssuer() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "system"
 	}
+	return "system@" + hostname
+}
+
+// sendEnrollment responds with a pending secret of the user and how to add it to an
+// authenticator app.
+func sendEnrollment(c *fiber.Ctx, name, secret string) error {
+	key, err := auth.TOTPKey(totpIssuer(), name, secret)
+	if err != nil {
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	}
+	qr, err := auth.QRCodePNG(key, 256)
+	if err != nil {
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(fiber.Map{
+		"user":        name,
+		"secret":      secret,
+		"otpauth_uri": key.URL(),
+		"algorithm":   auth.TOTPAlgorithm.String(),
+		"digits":      auth.TOTPDigits.Length(),
+		"period":      auth.TOTPPeriod,
+		"qr_code":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(qr),
+	})
 }
 
 func requireAuthMiddleware(c *fiber.Ctx) error {
-	if totpSecret == "" || jwtSecret == "" {
+	if !authEnabled() {
+		return c.Next()
+	}
+	if fromHub(c) {
+		setIdentity(c, "hub", auth.Role(conf().Agent.Role))
+		return c.Next()
+	}
+	if name, role, ok := clientCertIdentity(c); ok {
+		setIdentity(c, name, role)
+		c.Locals("client_cert", true)
+		return c.Next()
+	}
+	if name, role, ok := peerCredentialsIdentity(c); ok {
+		setIdentity(c, name, role)
+		return c.Next()
+	}
+	if bearer, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer "); ok {
+		if retryAfter, ok := authLimiter.Allow(c.IP()); !ok {
+			return sendLockedOut(c, retryAfter)
+		}
+		token, err := tokens.Authenticate(bearer)
+		if err != nil {
+			return authFailure(c, "", "api_token", err)
+		}
+		user, err := lookupUser(token.User)
+		if err != nil {
+			return authFailure(c, token.User, "api_token", errors.New("owner of api token no longer exists"))
+		}
+		authLimiter.Success(c.IP())
+		setIdentity(c, user.Name, user.Role)
+		c.Locals("token_id", token.ID)
+		c.Locals("scopes", token.Scopes)
 		return c.Next()
 	}
 	if auth_token := c.Cookies("auth_token"); auth_token != "" {
-		token, err := jwt.ParseWithClaims(auth_token, &jwt.RegisteredClaims{}, func(token *jwt.Token) (any, error) {
-			return []byte(jwtSecret), nil
+		var claims authClaims
+		token, err := jwt.ParseWithClaims(auth_token, &claims, func(token *jwt.Token) (any, error) {
+			return []byte(conf().Auth.JWTSecret), nil
 		})
 		if err == nil && token.Valid {
-			return c.Next()
+			_, sessionErr := sessions.Get(claims.ID)
+			_, userErr := lookupUser(claims.Subject)
+			if sessionErr == nil && userErr == nil {
+				setIdentity(c, claims.Subject, claims.Role)
+				c.Locals("session_id", claims.ID)
+				return c.Next()
+			}
 		}
 	}
-	auth := c.Get("Authorization")
-	if auth == "" {
-		return sendErrorMap(c, fiber.StatusUnauthorized, errors.New("missing Authorization header and auth_token cookie, authentication required; one must be provided"))
+	return sendErrorMap(c, fiber.StatusUnauthorized, errors.New("authentication required, log in at /api/v1/auth/login or provide an api token"))
+}
+
+// loginHandler verifies a TOTP code and starts a session for the user, returned in the
+// auth_token cookie.
+func loginHandler(c *fiber.Ctx) error {
+	if !loginEnabled() {
+		return sendErrorMap(c, fiber.StatusBadRequest, errors.New("logging in is disabled"))
+	}
+	var req struct {
+		User string `json:"user"` // defaults to the legacy admin user
+		Code string `json:"code"`
 	}
-	ok, err := totp.ValidateCustom(auth, totpSecret, time.Now(), totp.ValidateOpts{
-		Period:    30,
-		Skew:      1,
-		Digits:    8,
-		Algorithm: otp.AlgorithmSHA512,
-		Encoder:   otp.EncoderDefault,
-	})
-	if !ok || err != nil {
-		fmt.Println("TOTP validation error:", err)
-		return sendErrorMap(c, fiber.StatusUnauthorized, errors.New("invalid TOTP code or error validating"))
+	if err := c.BodyParser(&req); err != nil {
+		return sendErrorMap(c, fiber.StatusBadRequest, err)
+	}
+	if req.User == "" {
+		req.User = legacyUserName
+	}
+	if retryAfter, ok := authLimiter.Allow(c.IP()); !ok {
+		return sendLockedOut(c, retryAfter)
+	}
+	user, err := lookupUser(req.User)
+	if err != nil || !user.ValidateTOTP(req.Code) {
+		return authFailure(c, req.User, "totp", errors.New("invalid user or TOTP code"))
+	}
+	if !totpReplayGuard.Use(user.Name, req.Code) {
+		return authFailure(c, req.User, "totp", errors.New("TOTP code has already been used, wait for the next one"))
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+	authLimiter.Success(c.IP())
+
+	session, err := sessions.Create(user.Name, c.IP(), c.Get(fiber.HeaderUserAgent), conf().Auth.SessionLifetime.D())
+	if err != nil {
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, authClaims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID,
+			Subject:   user.Name,
+			IssuedAt:  jwt.NewNumericDate(session.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+		},
 	})
-	signed, err := token.SignedString([]byte(jwtSecret))
+	signed, err := token.SignedString([]byte(conf().Auth.JWTSecret))
 	if err != nil {
 		return sendErrorMap(c, fiber.StatusInternalServerError, err)
 	}
@@ -67,7 +240,144 @@ func requireAuthMiddleware(c *fiber.Ctx) error {
 		HTTPOnly: true,
 		Secure:   true,
 		SameSite: fiber.CookieSameSiteNoneMode,
-		Expires:  time.Now().Add(24 * time.Hour),
+		Expires:  session.ExpiresAt,
+	})
+	securityLog.Info("login", "event", "auth_success", "method", "totp", "user", user.Name, "ip", c.IP(), "session", session.ID)
+	return c.JSON(session)
+}
+
+// logoutHandler revokes the session of the request and clears the auth_token cookie.
+func logoutHandler(c *fiber.Ctx) error {
+	if id, ok := c.Locals("session_id").(string); ok {
+		if err := sessions.Revoke(id); err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		securityLog.Info("logout", "event", "logout", "user", identity(c), "ip", c.IP(), "session", id)
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     "auth_token",
+		Value:    "",
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: fiber.CookieSameSiteNoneMode,
+		Expires:  time.Unix(0, 0),
 	})
+	return sendErrorMap(c, fiber.StatusOK, nil)
+}
+
+// authFailure records a failed authentication attempt and responds with err.
+func authFailure(c *fiber.Ctx, user, method string, err error) error {
+	recordAuthFailure(c.IP(), user, method, err)
+	return sendErrorMap(c, fiber.StatusUnauthorized, err)
+}
+
+func recordAuthFailure(ip, user, method string, err error) {
+	securityLog.Warn("authentication failed", "event", "auth_failure", "method", method, "user", user, "ip", ip, "reason", err.Error())
+	lockout, global := authLimiter.Failure(ip)
+	if lockout > 0 {
+		if global {
+			securityLog.Error("too many failed authentication attempts from all clients, locking out everyone", "event", "global_lockout", "duration", lockout)
+		} else {
+			securityLog.Warn("too many failed authentication attempts, locking out client", "event", "lockout", "ip", ip, "duration", lockout)
+		}
+	}
+}
+
+func sendLockedOut(c *fiber.Ctx, retryAfter time.Duration) error {
+	securityLog.Warn("rejected authentication attempt during lockout", "event", "locked_out", "ip", c.IP())
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())+1))
+	return sendErrorMap(c, fiber.StatusTooManyRequests, fmt.Errorf("too many failed authentication attempts, try again in %s", retryAfter.Round(time.Second)))
+}
+
+// requirePermission rejects requests that may not use perm. it must come after
+// requireAuthMiddleware.
+func requirePermission(perm auth.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !can(c, perm) {
+			return sendErrorMap(c, fiber.StatusForbidden, fmt.Errorf("this action requires the %s permission", perm))
+		}
+		return c.Next()
+	}
+}
+
+// sessionOnlyMiddleware rejects requests authenticated with an api token, so that a
+// leaked token cannot be used to mint or revoke other tokens.
+func sessionOnlyMiddleware(c *fiber.Ctx) error {
+	if _, ok := c.Locals("token_id").(string); ok {
+		return sendErrorMap(c, fiber.StatusForbidden, errors.New("this action cannot be performed with an api token"))
+	}
+	if _, ok := c.Locals("client_cert").(bool); ok {
+		return sendErrorMap(c, fiber.StatusForbidden, errors.New("this action cannot be performed with a client certificate"))
+	}
+	if fromHub(c) {
+		return sendErrorMap(c, fiber.StatusForbidden, errors.New("this action cannot be performed through a hub"))
+	}
+	return c.Next()
+}
+
+// selfOrAdminMiddleware only lets admins and the user named by the :name parameter through.
+func selfOrAdminMiddleware(c *fiber.Ctx) error {
+	if c.Params("name") != identity(c) && !can(c, auth.PermAdmin) {
+		return sendErrorMap(c, fiber.StatusForbidden, errors.New("this action can only be performed by the user or an admin"))
+	}
 	return c.Next()
 }
+
+// can reports whether the request may use perm: the role must grant it and, for requests
+// authenticated with an api token, the token must be scoped to it.
+func can(c *fiber.Ctx, perm auth.Permission) bool {
+	scopes, ok := c.Locals("scopes").([]auth.Permission)
+	if ok && scopes == nil {
+		scopes = []auth.Permission{} // a token without scopes
+	}
+	return allowed(role(c), scopes, perm)
+}
+
+// allowed reports whether role may use perm, limited to scopes (of an api token) if not nil.
+func allowed(role auth.Role, scopes []auth.Permission, perm auth.Permission) bool {
+	if !authEnabled() {
+		return true
+	}
+	if !role.Can(perm) {
+		return false
+	}
+	if scopes != nil {
+		return slices.Contains(scopes, perm)
+	}
+	return true
+}
+
+// permissions returns every permission the request may use.
+func permissions(c *fiber.Ctx) []auth.Permission {
+	perms := []auth.Permission{}
+	for _, perm := range auth.RoleAdmin.Permissions() {
+		if can(c, perm) {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+func setIdentity(c *fiber.Ctx, name string, role auth.Role) {
+	c.Locals("identity", name)
+	c.Locals("role", role)
+}
+
+// identity returns the authenticated identity of the request, which is "anonymous"
+// when authentication is disabled.
+func identity(c *fiber.Ctx) string {
+	if id, ok := c.Locals("identity").(string); ok {
+		return id
+	}
+	return "anonymous"
+}
+
+// role returns the role of the authenticated identity of the request. every request is
+// treated as an admin when authentication is disabled.
+func role(c *fiber.Ctx) auth.Role {
+	if !authEnabled() {
+		return auth.RoleAdmin
+	}
+	r, _ := c.Locals("role").(auth.Role)
+	return r
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var ErrNoPendingEnrollment = errors.New("no TOTP enrollment is pending, start one first")
+var ErrInvalidCode = errors.New("invalid TOTP code")
+
+var b32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ValidateTOTP reports whether code is valid for the user's secret, or for their previous
+// secret while its grace period after a rotation lasts.
+func (u User) ValidateTOTP(code string) bool {
+	if u.TOTPSecret != "" && ValidateTOTP(code, u.TOTPSecret) {
+		return true
+	}
+	if u.PreviousTOTPSecret != "" && u.PreviousTOTPSecretExpiresAt != nil && time.Now().Before(*u.PreviousTOTPSecretExpiresAt) {
+		return ValidateTOTP(code, u.PreviousTOTPSecret)
+	}
+	return false
+}
+
+// StartEnrollment generates a new secret for the user and keeps it pending until a code
+// for it is verified with CompleteEnrollment. The current secret keeps working until then.
+func (s *UserStore) StartEnrollment(name string) (string, error) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return "", err
+	}
+	err = s.Update(name, func(u *User) error {
+		u.PendingTOTPSecret = secret
+		return nil
+	})
+	return secret, err
+}
+
+// CompleteEnrollment activates the pending secret of the user if code is valid for it. A
+// secret that is replaced keeps being accepted for gracePeriod.
+func (s *UserStore) CompleteEnrollment(name, code string, gracePeriod time.Duration) error {
+	return s.Update(name, func(u *User) error {
+		if u.PendingTOTPSecret == "" {
+			return ErrNoPendingEnrollment
+		}
+		if !ValidateTOTP(code, u.PendingTOTPSecret) {
+			return ErrInvalidCode
+		}
+		u.PreviousTOTPSecret, u.PreviousTOTPSecretExpiresAt = "", nil
+		if u.TOTPSecret != "" && gracePeriod > 0 {
+			expiresAt := time.Now().Add(gracePeriod)
+			u.PreviousTOTPSecret = u.TOTPSecret
+			u.PreviousTOTPSecretExpiresAt = &expiresAt
+		}
+		u.TOTPSecret = u.PendingTOTPSecret
+		u.PendingTOTPSecret = ""
+		return nil
+	})
+}
+
+// TOTPKey returns the key for secret, whose otpauth:// URL tells authenticator apps the
+// algorithm, digits and period to use.
+func TOTPKey(issuer, account, secret string) (*otp.Key, error) {
+	raw, err := b32NoPadding.DecodeString(strings.TrimRight(strings.ToUpper(secret), "="))
+	if err != nil {
+		return nil, err
+	}
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: account,
+		Period:      TOTPPeriod,
+		Digits:      TOTPDigits,
+		Algorithm:   TOTPAlgorithm,
+		Secret:      raw,
+	})
+}
+
+// QRCodePNG encodes the otpauth:// URL of key as a QR code PNG image.
+func QRCodePNG(key *otp.Key, size int) ([]byte, error) {
+	img, err := key.Image(size, size)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+type LimiterOptions struct {
+	MaxFailures       int           // failures from one key before it is locked out
+	GlobalMaxFailures int           // failures from all keys before everyone is locked out
+	Window            time.Duration // failures older than this are forgotten
+	BaseLockout       time.Duration // first lockout duration, doubled for each lockout after it
+	MaxLockout        time.Duration
+}
+
+var DefaultLimiterOptions = LimiterOptions{
+	MaxFailures:       5,
+	GlobalMaxFailures: 100,
+	Window:            15 * time.Minute,
+	BaseLockout:       time.Minute,
+	MaxLockout:        time.Hour,
+}
+
+type limitState struct {
+	failures    int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// failure records a failure at now and reports how long the state is locked out for,
+// which is 0 if this failure did not cause a lockout.
+func (s *limitState) failure(now time.Time, max int, opts LimiterOptions) time.Duration {
+	if now.Sub(s.lastFailure) > opts.Window {
+		s.failures = 0
+		if now.Sub(s.lockedUntil) > opts.Window {
+			s.lockouts = 0
+		}
+	}
+	s.failures++
+	s.lastFailure = now
+	if s.failures < max {
+		return 0
+	}
+	lockout := opts.BaseLockout << s.lockouts
+	if lockout > opts.MaxLockout || lockout <= 0 {
+		lockout = opts.MaxLockout
+	}
+	s.failures = 0
+	s.lockouts++
+	s.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+// Limiter limits failed authentication attempts per key (e.g: client IP) and globally,
+// locking out for exponentially longer periods.
+type Limiter struct {
+	opts      LimiterOptions
+	mu        sync.Mutex
+	keys      map[string]*limitState
+	global    limitState
+	lastSweep time.Time
+}
+
+// Allow reports whether key may attempt to authenticate. If not, it returns how long
+// until it may try again.
+func (l *Limiter) Allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Before(l.global.lockedUntil) {
+		return l.global.lockedUntil.Sub(now), false
+	}
+	if s, ok := l.keys[key]; ok && now.Before(s.lockedUntil) {
+		return s.lockedUntil.Sub(now), false
+	}
+	return 0, true
+}
+
+// Failure records a failed attempt by key. It returns the lockout duration if the failure
+// locked out key (or everyone, if global is true), otherwise 0.
+func (l *Limiter) Failure(key string) (lockout time.Duration, global bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.sweep(now)
+	s, ok := l.keys[key]
+	if !ok {
+		s = &limitState{}
+		l.keys[key] = s
+	}
+	// the failure counts against key even if it locks out everyone, so a key that keeps
+	// causing global lockouts is locked out for longer on its own
+	keyLockout := s.failure(now, l.opts.MaxFailures, l.opts)
+	if lockout := l.global.failure(now, l.opts.GlobalMaxFailures, l.opts); lockout > 0 {
+		return lockout, true
+	}
+	return keyLockout, false
+}
+
+// Success forgets the failed attempts of key.
+func (l *Limiter) Success(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.keys, key)
+}
+
+// sweep removes keys that are neither locked out nor have recent failures.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.opts.Window {
+		return
+	}
+	l.lastSweep = now
+	for key, s := range l.keys {
+		if now.Sub(s.lastFailure) > l.opts.Window && now.After(s.lockedUntil) {
+			delete(l.keys, key)
+		}
+	}
+}
+
+func NewLimiter(opts LimiterOptions) *Limiter {
+	return &Limiter{
+		opts: opts,
+		keys: make(map[string]*limitState),
+	}
+}
+
+// ReplayGuard remembers TOTP codes that have been used so that each code can only be
+// used once within the window it is valid in.
+type ReplayGuard struct {
+	mu   sync.Mutex
+	used map[string]time.Time // user + code -> when it can be forgotten
+}
+
+// Use marks code as used by user. It returns false if the code has already been used.
+func (g *ReplayGuard) Use(user, code string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	for k, expiry := range g.used {
+		if now.After(expiry) {
+			delete(g.used, k)
+		}
+	}
+	key := user + ":" + code
+	if _, ok := g.used[key]; ok {
+		return false
+	}
+	// with a skew of 1, a code is accepted from one period before its own until one after it
+	g.used[key] = now.Add(3 * TOTPPeriod * time.Second)
+	return true
+}
+
+func NewReplayGuard() *ReplayGuard {
+	return &ReplayGuard{used: make(map[string]time.Time)}
+}
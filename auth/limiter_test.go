@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGlobalLockoutCountsAgainstKey(t *testing.T) {
+	l := NewLimiter(LimiterOptions{
+		MaxFailures:       3,
+		GlobalMaxFailures: 3,
+		Window:            time.Hour,
+		BaseLockout:       time.Minute,
+		MaxLockout:        time.Hour,
+	})
+	for range 2 {
+		if lockout, _ := l.Failure("10.0.0.1"); lockout != 0 {
+			t.Fatalf("locked out for %s before reaching the limit", lockout)
+		}
+	}
+	if _, global := l.Failure("10.0.0.1"); !global {
+		t.Fatal("the third failure didn't lock out everyone")
+	}
+	if _, ok := l.Allow("10.0.0.2"); ok {
+		t.Fatal("another key is allowed during the global lockout")
+	}
+	l.global.lockedUntil = time.Time{} // the global lockout is over
+	if _, ok := l.Allow("10.0.0.2"); !ok {
+		t.Fatal("another key is locked out after the global lockout")
+	}
+	if _, ok := l.Allow("10.0.0.1"); ok {
+		t.Fatal("the key that caused the global lockout isn't locked out itself")
+	}
+}
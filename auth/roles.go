@@ -0,0 +1,41 @@
+package auth
+
+import "slices"
+
+type Permission string
+
+const (
+	PermReadMetrics     Permission = "read_metrics"     // system info, processes, services
+	PermReadLogs        Permission = "read_logs"        // system and service logs
+	PermSignalProcesses Permission = "signal_processes" // send signals to processes
+	PermManageServices  Permission = "manage_services"  // start, stop, restart services
+	PermPowerActions    Permission = "power_actions"    // shutdown, reboot, sleep, scheduling
+	PermAdmin           Permission = "admin"            // users, audit log
+)
+
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:   {PermReadMetrics, PermReadLogs},
+	RoleOperator: {PermReadMetrics, PermReadLogs, PermSignalProcesses, PermManageServices},
+	RoleAdmin:    {PermReadMetrics, PermReadLogs, PermSignalProcesses, PermManageServices, PermPowerActions, PermAdmin},
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+func (r Role) Can(perm Permission) bool {
+	return slices.Contains(rolePermissions[r], perm)
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a login of a user, identified by the ID (jti claim) of the JWT issued for it.
+type Session struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	ClientIP  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionStore is the registry of active sessions, persisted as JSON in a file. A JWT is
+// only accepted while its session is in the registry, so removing a session revokes it.
+type SessionStore struct {
+	path     string
+	mu       sync.RWMutex
+	sessions []Session
+}
+
+func (s *SessionStore) Create(user, clientIP, userAgent string, lifetime time.Duration) (Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Session{}, err
+	}
+	session := Session{
+		ID:        hex.EncodeToString(id),
+		User:      user,
+		ClientIP:  clientIP,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(lifetime),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = append(s.sessions, session)
+	return session, s.save()
+}
+
+// Get returns the session with the given ID if it has not expired or been revoked.
+func (s *SessionStore) Get(id string) (Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := slices.IndexFunc(s.sessions, func(session Session) bool { return session.ID == id })
+	if i == -1 || time.Now().After(s.sessions[i].ExpiresAt) {
+		return Session{}, ErrSessionNotFound
+	}
+	return s.sessions[i], nil
+}
+
+// List returns the active sessions of user, or every active session if user is empty.
+func (s *SessionStore) List(user string) []Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sessions := []Session{}
+	for _, session := range s.sessions {
+		if (user == "" || session.User == user) && time.Now().Before(session.ExpiresAt) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+func (s *SessionStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.sessions, func(session Session) bool { return session.ID == id })
+	if i == -1 {
+		return ErrSessionNotFound
+	}
+	s.sessions = slices.Delete(s.sessions, i, i+1)
+	return s.save()
+}
+
+// RevokeAll revokes every session of user, or every session if user is empty. It returns
+// the number of sessions revoked.
+func (s *SessionStore) RevokeAll(user string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.sessions)
+	s.sessions = slices.DeleteFunc(s.sessions, func(session Session) bool {
+		return user == "" || session.User == user
+	})
+	return n - len(s.sessions), s.save()
+}
+
+// save persists the sessions, dropping the ones that have expired.
+func (s *SessionStore) save() error {
+	now := time.Now()
+	s.sessions = slices.DeleteFunc(s.sessions, func(session Session) bool {
+		return now.After(session.ExpiresAt)
+	})
+	return writeJSONFile(s.path, s.sessions)
+}
+
+// NewSessionStore loads the sessions stored at path. The file does not need to exist.
+func NewSessionStore(path string) (*SessionStore, error) {
+	s := &SessionStore{path: path}
+	if err := readJSONFile(path, &s.sessions); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TokenPrefix is prepended to every API token so they are easy to recognize (e.g: by secret scanners).
+const TokenPrefix = "sys_"
+
+var ErrTokenNotFound = errors.New("api token not found")
+var ErrTokenExpired = errors.New("api token expired")
+var ErrInvalidScope = errors.New("invalid scope")
+
+// APIToken is a long-lived credential for automation. Only a hash of the token is stored.
+type APIToken struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"` // what the token is used for
+	User      string       `json:"user"` // requests made with the token act as this user
+	Scopes    []Permission `json:"scopes"`
+	Hash      string       `json:"-"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+}
+
+// storedToken exists so that the hash is persisted while never being sent in API responses.
+type storedToken struct {
+	APIToken
+	Hash string `json:"hash"`
+}
+
+func (t APIToken) Expired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// TokenStore holds the API tokens, persisted as JSON in a file.
+type TokenStore struct {
+	path   string
+	mu     sync.RWMutex
+	tokens []APIToken
+}
+
+// Create creates a token for user. The returned plaintext token is not stored anywhere.
+func (s *TokenStore) Create(user, name string, scopes []Permission, expiresAt *time.Time) (string, APIToken, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(RoleAdmin.Permissions(), scope) {
+			return "", APIToken{}, ErrInvalidScope
+		}
+	}
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", APIToken{}, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIToken{}, err
+	}
+	plaintext := TokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	t := APIToken{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		User:      user,
+		Scopes:    scopes,
+		Hash:      HashToken(plaintext),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = append(s.tokens, t)
+	if err := s.save(); err != nil {
+		s.tokens = s.tokens[:len(s.tokens)-1]
+		return "", APIToken{}, err
+	}
+	return plaintext, t, nil
+}
+
+// Authenticate returns the token matching plaintext if it exists and has not expired.
+func (s *TokenStore) Authenticate(plaintext string) (APIToken, error) {
+	hash := HashToken(plaintext)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := slices.IndexFunc(s.tokens, func(t APIToken) bool { return t.Hash == hash })
+	if i == -1 {
+		return APIToken{}, ErrTokenNotFound
+	}
+	if s.tokens[i].Expired() {
+		return APIToken{}, ErrTokenExpired
+	}
+	return s.tokens[i], nil
+}
+
+func (s *TokenStore) Get(id string) (APIToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := slices.IndexFunc(s.tokens, func(t APIToken) bool { return t.ID == id })
+	if i == -1 {
+		return APIToken{}, ErrTokenNotFound
+	}
+	return s.tokens[i], nil
+}
+
+// List returns the tokens of user, or every token if user is empty.
+func (s *TokenStore) List(user string) []APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tokens := []APIToken{}
+	for _, t := range s.tokens {
+		if user == "" || t.User == user {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+func (s *TokenStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.tokens, func(t APIToken) bool { return t.ID == id })
+	if i == -1 {
+		return ErrTokenNotFound
+	}
+	s.tokens = slices.Delete(s.tokens, i, i+1)
+	return s.save()
+}
+
+// RevokeAll revokes every token of user, or every token if user is empty. It returns the
+// number of tokens revoked.
+func (s *TokenStore) RevokeAll(user string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.tokens)
+	s.tokens = slices.DeleteFunc(s.tokens, func(t APIToken) bool {
+		return user == "" || t.User == user
+	})
+	return n - len(s.tokens), s.save()
+}
+
+func (s *TokenStore) save() error {
+	stored := make([]storedToken, len(s.tokens))
+	for i, t := range s.tokens {
+		stored[i] = storedToken{APIToken: t, Hash: t.Hash}
+	}
+	return writeJSONFile(s.path, stored)
+}
+
+// NewTokenStore loads the tokens stored at path. The file does not need to exist.
+func NewTokenStore(path string) (*TokenStore, error) {
+	var stored []storedToken
+	if err := readJSONFile(path, &stored); err != nil {
+		return nil, err
+	}
+	s := &TokenStore{path: path, tokens: make([]APIToken, len(stored))}
+	for i, st := range stored {
+		st.APIToken.Hash = st.Hash
+		s.tokens[i] = st.APIToken
+	}
+	return s, nil
+}
+
+// HashToken returns the hash a token is stored as, so that a leaked store doesn't leak
+// usable tokens.
+func HashToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+var ErrUserExists = errors.New("user already exists")
+var ErrInvalidUserName = errors.New("user name must be 1-32 characters of a-z, 0-9, _, . or -")
+var ErrInvalidRole = errors.New("invalid role")
+
+var userNameRegexp = regexp.MustCompile(`^[a-z0-9_.-]{1,32}$`)
+
+// TOTP parameters used for every user. Authenticator apps need to be told these
+// explicitly since they differ from the common SHA1/6 digit defaults.
+const (
+	TOTPPeriod    = 30
+	TOTPDigits    = otp.Digits(8)
+	TOTPAlgorithm = otp.AlgorithmSHA512
+)
+
+type User struct {
+	Name       string    `json:"name"`
+	Role       Role      `json:"role"`
+	TOTPSecret string    `json:"totp_secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	PendingTOTPSecret           string     `json:"pending_totp_secret,omitempty"`             // being enrolled, not accepted yet
+	PreviousTOTPSecret          string     `json:"previous_totp_secret,omitempty"`            // replaced, accepted until it expires
+	PreviousTOTPSecretExpiresAt *time.Time `json:"previous_totp_secret_expires_at,omitempty"` // end of the grace period
+}
+
+// ValidateTOTP reports whether code is currently valid for the base32 encoded secret.
+func ValidateTOTP(code, secret string) bool {
+	ok, err := totp.ValidateCustom(code, secret, time.Now(), totp.ValidateOpts{
+		Period:    TOTPPeriod,
+		Skew:      1,
+		Digits:    TOTPDigits,
+		Algorithm: TOTPAlgorithm,
+		Encoder:   otp.EncoderDefault,
+	})
+	return ok && err == nil
+}
+
+// UserStore holds the user accounts, persisted as JSON in a file.
+type UserStore struct {
+	path  string
+	mu    sync.RWMutex
+	users []User
+}
+
+func (s *UserStore) Get(name string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := slices.IndexFunc(s.users, func(u User) bool { return u.Name == name })
+	if i == -1 {
+		return User{}, ErrUserNotFound
+	}
+	return s.users[i], nil
+}
+
+// List returns every user without their TOTP secrets.
+func (s *UserStore) List() []User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]User, len(s.users))
+	for i, u := range s.users {
+		u.TOTPSecret, u.PendingTOTPSecret, u.PreviousTOTPSecret = "", "", ""
+		users[i] = u
+	}
+	return users
+}
+
+// Len returns the number of users that have an active TOTP secret, i.e: that can log in.
+func (s *UserStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, u := range s.users {
+		if u.TOTPSecret != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *UserStore) Create(u User) error {
+	if !userNameRegexp.MatchString(u.Name) {
+		return ErrInvalidUserName
+	}
+	if !u.Role.Valid() {
+		return ErrInvalidRole
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.users, func(o User) bool { return o.Name == u.Name }) {
+		return ErrUserExists
+	}
+	u.CreatedAt = time.Now()
+	s.users = append(s.users, u)
+	return s.save()
+}
+
+// Update applies fn to the user named name and persists the result.
+func (s *UserStore) Update(name string, fn func(u *User) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.users, func(u User) bool { return u.Name == name })
+	if i == -1 {
+		return ErrUserNotFound
+	}
+	u := s.users[i]
+	if err := fn(&u); err != nil {
+		return err
+	}
+	if !u.Role.Valid() {
+		return ErrInvalidRole
+	}
+	s.users[i] = u
+	return s.save()
+}
+
+func (s *UserStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.users, func(u User) bool { return u.Name == name })
+	if i == -1 {
+		return ErrUserNotFound
+	}
+	s.users = slices.Delete(s.users, i, i+1)
+	return s.save()
+}
+
+func (s *UserStore) save() error {
+	return writeJSONFile(s.path, s.users)
+}
+
+// NewUserStore loads the users stored at path. The file does not need to exist.
+func NewUserStore(path string) (*UserStore, error) {
+	s := &UserStore{path: path}
+	if err := readJSONFile(path, &s.users); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GenerateTOTPSecret returns a new random base32 encoded TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSONFile atomically replaces the file at path with v encoded as JSON.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data)
+}
+
+// WriteFileAtomic replaces the file at path with data by writing a temporary file and
+// renaming it, so the file is never left half-written. The file (and its directory, if it
+// is created) is only accessible by the owner, since it may hold secrets.
+func WriteFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/config"
+)
+
+// code returns the TOTP code of secret periods periods from now. the codes of the periods
+// before and after the current one are accepted too, so each user can log in three times
+// before the replay guard rejects the codes.
+func code(t *testing.T, secret string, periods int) string {
+	t.Helper()
+	at := time.Now().Add(time.Duration(periods*auth.TOTPPeriod) * time.Second)
+	code, err := totp.GenerateCodeCustom(secret, at, totp.ValidateOpts{
+		Period:    auth.TOTPPeriod,
+		Digits:    auth.TOTPDigits,
+		Algorithm: auth.TOTPAlgorithm,
+		Encoder:   otp.EncoderDefault,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func secret(t *testing.T) string {
+	t.Helper()
+	s, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newAuthServer returns a test server with login enabled and the legacy admin user, whose
+// secret is returned.
+func newAuthServer(t *testing.T, configure func(c *config.Config)) (*testServer, string) {
+	adminSecret := secret(t)
+	s := newTestServer(t, func(c *config.Config) {
+		c.Auth.JWTSecret = "test jwt secret"
+		c.Auth.TOTPSecret = adminSecret
+		if configure != nil {
+			configure(c)
+		}
+	})
+	return s, adminSecret
+}
+
+// login logs the user in with code and returns the Cookie header of the session.
+func (s *testServer) login(user, code string) (string, auth.Session) {
+	s.t.Helper()
+	var session auth.Session
+	resp := s.call("POST", "/api/v1/auth/login", map[string]string{"user": user, "code": code}, 200, &session)
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "auth_token" {
+			return "auth_token=" + cookie.Value, session
+		}
+	}
+	s.t.Fatalf("login as %s set no auth_token cookie", user)
+	return "", session
+}
+
+type authResponse struct {
+	User        string
+	Role        auth.Role
+	Permissions []auth.Permission
+}
+
+func TestLogin(t *testing.T) {
+	s, adminSecret := newAuthServer(t, nil)
+	s.call("GET", "/api/v1/info", nil, 401, nil)
+	s.call("GET", "/api/v1/openapi.json", nil, 200, nil)
+	s.call("POST", "/api/v1/auth/login", map[string]string{"code": "12345678"}, 401, nil)
+	s.call("POST", "/api/v1/auth/login", map[string]string{"user": "nobody", "code": code(t, adminSecret, 0)}, 401, nil)
+
+	admin := code(t, adminSecret, 0)
+	cookie, session := s.login("", admin)
+	if session.User != "admin" {
+		t.Fatalf("session = %+v", session)
+	}
+	var me authResponse
+	s.call("GET", "/api/v1/auth", nil, 200, &me, "Cookie", cookie)
+	if me.User != "admin" || me.Role != auth.RoleAdmin || len(me.Permissions) != len(auth.RoleAdmin.Permissions()) {
+		t.Fatalf("GET /api/v1/auth = %+v", me)
+	}
+	s.call("GET", "/api/v1/info", nil, 200, nil, "Cookie", cookie)
+	s.call("POST", "/api/v1/auth/login", map[string]string{"code": admin}, 401, nil) // replayed
+
+	s.call("POST", "/api/v1/auth/logout", nil, 200, nil, "Cookie", cookie)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Cookie", cookie)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Cookie", "auth_token=forged")
+
+	conf().Auth.JWTSecret = ""
+	s.call("POST", "/api/v1/auth/login", map[string]string{"code": code(t, adminSecret, 1)}, 400, nil)
+}
+
+func TestLockout(t *testing.T) {
+	s, adminSecret := newAuthServer(t, nil)
+	for range auth.DefaultLimiterOptions.MaxFailures {
+		s.call("POST", "/api/v1/auth/login", map[string]string{"code": "00000000"}, 401, nil)
+	}
+	resp := s.call("POST", "/api/v1/auth/login", map[string]string{"code": code(t, adminSecret, 0)}, 429, nil)
+	if retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After")); retryAfter < 1 {
+		t.Fatalf("Retry-After = %q", resp.Header.Get("Retry-After"))
+	}
+	s.call("GET", "/api/v1/info", nil, 429, nil, "Authorization", "Bearer sys_whatever")
+}
+
+func TestRoles(t *testing.T) {
+	s, adminSecret := newAuthServer(t, nil)
+	admin, _ := s.login("admin", code(t, adminSecret, 0))
+	cookies := map[auth.Role]string{auth.RoleAdmin: admin}
+	for _, role := range []auth.Role{auth.RoleViewer, auth.RoleOperator} {
+		userSecret := secret(t)
+		s.call("POST", "/api/v1/users", map[string]any{"name": string(role), "role": role, "totp_secret": userSecret}, 200, nil, "Cookie", admin)
+		cookies[role], _ = s.login(string(role), code(t, userSecret, 0))
+	}
+	tests := []struct {
+		method, path string
+		allowed      auth.Role // and every role above it
+	}{
+		{"GET", "/api/v1/info", auth.RoleViewer},
+		{"GET", "/api/v1/system/logs", auth.RoleViewer},
+		{"POST", "/api/v1/process/42/signal/SIGSTOP", auth.RoleOperator},
+		{"PATCH", "/api/v1/service/nginx.service/restart", auth.RoleOperator},
+		{"POST", "/api/v1/system/reboot", auth.RoleAdmin},
+		{"GET", "/api/v1/audit", auth.RoleAdmin},
+		{"GET", "/api/v1/users", auth.RoleAdmin},
+	}
+	levels := []auth.Role{auth.RoleViewer, auth.RoleOperator, auth.RoleAdmin}
+	for _, tt := range tests {
+		for i, role := range levels {
+			want := 403
+			if i >= slices.Index(levels, tt.allowed) {
+				want = 200
+			}
+			if got := s.request(tt.method, tt.path, nil, "Cookie", cookies[role]).StatusCode; got != want {
+				t.Errorf("%s %s as %s = %d, want %d", tt.method, tt.path, role, got, want)
+			}
+		}
+	}
+
+	// changing the role of a user logs them out
+	s.call("PATCH", "/api/v1/users/viewer", map[string]any{"role": auth.RoleOperator}, 200, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Cookie", cookies[auth.RoleViewer])
+	s.call("PATCH", "/api/v1/users/nobody", map[string]any{"role": auth.RoleOperator}, 404, nil, "Cookie", admin)
+	s.call("DELETE", "/api/v1/users/operator", nil, 200, nil, "Cookie", admin)
+	s.call("DELETE", "/api/v1/users/operator", nil, 404, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Cookie", cookies[auth.RoleOperator])
+
+	var users []auth.User
+	s.call("GET", "/api/v1/users", nil, 200, &users, "Cookie", admin)
+	if len(users) != 1 || users[0].Name != "viewer" || users[0].Role != auth.RoleOperator {
+		t.Fatalf("users = %+v", users)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	s, adminSecret := newAuthServer(t, nil)
+	first, firstSession := s.login("admin", code(t, adminSecret, -1))
+	second, _ := s.login("admin", code(t, adminSecret, 0))
+	var list []auth.Session
+	s.call("GET", "/api/v1/sessions", nil, 200, &list, "Cookie", second)
+	if len(list) != 2 {
+		t.Fatalf("sessions = %+v, want 2", list)
+	}
+	s.call("DELETE", "/api/v1/sessions/"+firstSession.ID, nil, 200, nil, "Cookie", second)
+	s.call("DELETE", "/api/v1/sessions/"+firstSession.ID, nil, 404, nil, "Cookie", second)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Cookie", first)
+
+	var revoked struct{ Revoked int }
+	s.call("POST", "/api/v1/sessions/revoke_all", nil, 200, &revoked, "Cookie", second)
+	if revoked.Revoked != 1 {
+		t.Fatalf("revoked %d sessions, want 1", revoked.Revoked)
+	}
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Cookie", second)
+}
+
+func TestAPITokens(t *testing.T) {
+	s, adminSecret := newAuthServer(t, nil)
+	admin, _ := s.login("admin", code(t, adminSecret, 0))
+	var created struct {
+		Token   string
+		Details auth.APIToken
+	}
+	s.call("POST", "/api/v1/tokens", map[string]any{"name": "monitoring", "scopes": []auth.Permission{auth.PermReadMetrics}}, 200, &created, "Cookie", admin)
+	bearer := "Bearer " + created.Token
+	s.call("GET", "/api/v1/info", nil, 200, nil, "Authorization", bearer)
+	s.call("GET", "/api/v1/system/logs", nil, 403, nil, "Authorization", bearer)
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 403, nil, "Authorization", bearer)
+	// tokens can't be used to create or revoke tokens
+	s.call("POST", "/api/v1/tokens", map[string]any{"name": "more"}, 403, nil, "Authorization", bearer)
+	s.call("DELETE", "/api/v1/tokens/"+created.Details.ID, nil, 403, nil, "Authorization", bearer)
+	s.call("POST", "/api/v1/users/admin/enroll", nil, 403, nil, "Authorization", bearer)
+
+	var list []auth.APIToken
+	s.call("GET", "/api/v1/tokens", nil, 200, &list, "Authorization", bearer)
+	if len(list) != 1 || list[0].Name != "monitoring" {
+		t.Fatalf("tokens = %+v", list)
+	}
+
+	// a token can only be scoped to permissions of the user
+	viewerSecret := secret(t)
+	s.call("POST", "/api/v1/users", map[string]any{"name": "viewer", "role": auth.RoleViewer, "totp_secret": viewerSecret}, 200, nil, "Cookie", admin)
+	viewer, _ := s.login("viewer", code(t, viewerSecret, 0))
+	s.call("POST", "/api/v1/tokens", map[string]any{"name": "ops", "scopes": []auth.Permission{auth.PermManageServices}}, 403, nil, "Cookie", viewer)
+	s.call("DELETE", "/api/v1/tokens/"+created.Details.ID, nil, 404, nil, "Cookie", viewer) // not theirs
+
+	s.call("DELETE", "/api/v1/tokens/"+created.Details.ID, nil, 200, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Authorization", bearer)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Authorization", "Bearer sys_invalid")
+
+	// deleting a user revokes their tokens, so they don't work for a new user with the same name
+	var viewerToken struct{ Token string }
+	s.call("POST", "/api/v1/tokens", map[string]any{"name": "dashboard"}, 200, &viewerToken, "Cookie", viewer)
+	s.call("DELETE", "/api/v1/users/viewer", nil, 200, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/tokens", nil, 200, &list, "Cookie", admin)
+	if len(list) != 0 {
+		t.Fatalf("tokens after deleting their user = %+v", list)
+	}
+	s.call("POST", "/api/v1/users", map[string]any{"name": "viewer", "role": auth.RoleAdmin, "totp_secret": secret(t)}, 200, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/info", nil, 401, nil, "Authorization", "Bearer "+viewerToken.Token)
+}
+
+func TestEnrollment(t *testing.T) {
+	s, adminSecret := newAuthServer(t, nil)
+	admin, _ := s.login("admin", code(t, adminSecret, 0))
+	var enrollment struct {
+		Secret     string
+		OTPAuthURI string `json:"otpauth_uri"`
+		QRCode     string `json:"qr_code"`
+	}
+	s.call("POST", "/api/v1/users", map[string]any{"name": "alice", "role": auth.RoleViewer}, 200, &enrollment, "Cookie", admin)
+	if enrollment.Secret == "" || !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/") || !strings.HasPrefix(enrollment.QRCode, "data:image/png;base64,") {
+		t.Fatalf("enrollment = %+v", enrollment)
+	}
+	s.call("POST", "/api/v1/users", map[string]any{"name": "alice", "role": auth.RoleViewer}, 400, nil, "Cookie", admin)
+	// alice can't log in before completing the enrollment
+	s.call("POST", "/api/v1/auth/login", map[string]string{"user": "alice", "code": code(t, enrollment.Secret, 0)}, 401, nil)
+
+	resp := s.request("GET", "/api/v1/users/alice/enroll/qr.png", nil, "Cookie", admin)
+	if qr, _ := io.ReadAll(resp.Body); resp.StatusCode != 200 || !strings.HasPrefix(string(qr), "\x89PNG") {
+		t.Fatalf("GET qr.png = %d %.16q", resp.StatusCode, qr)
+	}
+	s.call("POST", "/api/v1/users/alice/enroll/verify", map[string]string{"code": "00000000"}, 400, nil, "Cookie", admin)
+	s.call("POST", "/api/v1/users/alice/enroll/verify", map[string]string{"code": code(t, enrollment.Secret, 0)}, 200, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/users/alice/enroll/qr.png", nil, 404, nil, "Cookie", admin)
+	s.call("POST", "/api/v1/users/nobody/enroll/verify", map[string]string{"code": "00000000"}, 404, nil, "Cookie", admin)
+	alice, _ := s.login("alice", code(t, enrollment.Secret, 1))
+
+	// users can rotate their own secret, but not the secret of others
+	s.call("POST", "/api/v1/users/admin/enroll", nil, 403, nil, "Cookie", alice)
+	s.call("POST", "/api/v1/users/alice/enroll", nil, 200, &enrollment, "Cookie", alice)
+	s.call("POST", "/api/v1/users/alice/enroll/verify", map[string]any{"code": code(t, enrollment.Secret, 0), "grace_period": 0}, 200, nil, "Cookie", alice)
+	s.login("alice", code(t, enrollment.Secret, 1))
+
+	// the legacy admin is stored when it enrolls, and keeps its old secret for the grace period
+	s.call("POST", "/api/v1/users/admin/enroll", nil, 200, &enrollment, "Cookie", admin)
+	s.call("POST", "/api/v1/users/admin/enroll/verify", map[string]string{"code": code(t, enrollment.Secret, 0)}, 200, nil, "Cookie", admin)
+	s.login("admin", code(t, adminSecret, 1))
+	s.login("admin", code(t, enrollment.Secret, 1))
+}
+
+// testCA is a certificate authority for client certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test client CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue returns a client certificate for name signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// get sends a GET request with client and returns the status and body of the response.
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestClientCertificates(t *testing.T) {
+	ca := newTestCA(t)
+	s := newTestServer(t, func(c *config.Config) {
+		c.TLS.SelfSigned = true
+		c.TLS.ClientCAFile = ca.file
+		c.TLS.ClientCerts = []config.ClientCertConfig{
+			{Match: "laptop", User: "laptop", Role: string(auth.RoleViewer)},
+			{Match: "ghost", User: "nobody"}, // takes the role of a user that doesn't exist
+		}
+	})
+	tlsConfig, err := tlsInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.app.Listener(tls.NewListener(ln, tlsConfig))
+	t.Cleanup(func() { s.app.ShutdownWithTimeout(time.Second) })
+	url := "https://" + ln.Addr().String() + "/api/v1/auth"
+
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			Certificates:       certs,
+			InsecureSkipVerify: true, // the self-signed certificate isn't what's tested
+		}}}
+	}
+	status, body := get(t, client(ca.issue(t, "laptop")), url)
+	if status != 200 || !strings.Contains(body, `"user":"laptop"`) || !strings.Contains(body, `"role":"viewer"`) {
+		t.Fatalf("GET /api/v1/auth with a client certificate = %d %s", status, body)
+	}
+	if status, _ := get(t, client(ca.issue(t, "laptop")), "https://"+ln.Addr().String()+"/api/v1/audit"); status != 403 {
+		t.Fatalf("GET /api/v1/audit as a viewer = %d, want 403", status)
+	}
+	for _, c := range []*http.Client{client(), client(ca.issue(t, "desktop")), client(ca.issue(t, "ghost"))} {
+		if status, body := get(t, c, url); status != 401 {
+			t.Fatalf("GET /api/v1/auth = %d %s, want 401", status, body)
+		}
+	}
+}
+
+func TestPeerCredentials(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	s := newTestServer(t, func(c *config.Config) {
+		c.Auth.LocalUsers = []config.LocalUserConfig{{UnixUser: strconv.Itoa(os.Getuid()), User: "me", Role: string(auth.RoleOperator)}}
+	})
+	path := filepath.Join(t.TempDir(), "system.sock")
+	ln, err := listenUnix(config.ListenerConfig{Network: "unix", Address: path, Mode: "0600"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.app.Listener(ln)
+	t.Cleanup(func() { s.app.ShutdownWithTimeout(time.Second) })
+	client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", path)
+	}}}
+
+	status, body := get(t, client, "http://unix/api/v1/auth")
+	if status != 200 || !strings.Contains(body, `"user":"me"`) || !strings.Contains(body, `"role":"operator"`) {
+		t.Fatalf("GET /api/v1/auth over the socket = %d %s", status, body)
+	}
+	if status, _ := get(t, client, "http://unix/api/v1/audit"); status != 403 {
+		t.Fatalf("GET /api/v1/audit as an operator = %d, want 403", status)
+	}
+	// the same request over tcp isn't authenticated
+	s.call("GET", "/api/v1/auth", nil, 401, nil)
+}
+
+func TestHubTunnel(t *testing.T) {
+	s, _ := newAuthServer(t, func(c *config.Config) { c.Agent.Role = string(auth.RoleViewer) })
+	client := &http.Client{Transport: appTransport{s.app}}
+	status, body := get(t, client, "http://agent/api/v1/auth")
+	if status != 200 || !strings.Contains(body, `"user":"hub"`) || !strings.Contains(body, `"role":"viewer"`) {
+		t.Fatalf("GET /api/v1/auth through the hub = %d %s", status, body)
+	}
+	if status, _ := get(t, client, "http://agent/api/v1/info?fields=cpu_usage"); status != 200 {
+		t.Fatalf("GET /api/v1/info through the hub = %d, want 200", status)
+	}
+	resp, err := client.Post("http://agent/api/v1/tokens", "application/json", strings.NewReader(`{"name":"hub"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 403 {
+		t.Fatalf("POST /api/v1/tokens through the hub = %d, want 403", resp.StatusCode)
+	}
+}
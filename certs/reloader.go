@@ -0,0 +1,80 @@
+package certs
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval is how often the files are checked for changes, at most.
+const checkInterval = 5 * time.Second
+
+// Reloader serves the certificate in a pair of files, reloading it when either file
+// changes. A pair that fails to load (e.g. because only one file has been replaced yet)
+// is logged and the previous certificate keeps being served.
+type Reloader struct {
+	certFile, keyFile string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.lastCheck) >= checkInterval {
+		r.lastCheck = time.Now()
+		if err := r.reload(); err != nil {
+			slog.Error("reload tls certificate, keeping the current one", "cert_file", r.certFile, "key_file", r.keyFile, "error", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// reload loads the pair if either file has been modified since it was last loaded.
+func (r *Reloader) reload() error {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	if r.cert != nil {
+		slog.Info("tls certificate reloaded", "cert_file", r.certFile)
+	}
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// NewReloader loads the certificate in certFile and keyFile.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, lastCheck: time.Now()}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
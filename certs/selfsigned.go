@@ -0,0 +1,230 @@
+// Package certs generates self-signed certificates and serves certificates that are
+// reloaded from disk when they change.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	caValidity     = 10 * 365 * 24 * time.Hour
+	serverValidity = 397 * 24 * time.Hour // the longest validity browsers accept
+	renewBefore    = 30 * 24 * time.Hour
+)
+
+// SelfSigned is a self-signed CA and a server certificate issued by it, stored in a
+// directory as ca.pem, ca-key.pem, cert.pem and key.pem.
+type SelfSigned struct {
+	Dir      string
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	CA   *x509.Certificate
+	Cert *x509.Certificate
+
+	// Generated is true if the server certificate was (re)issued by EnsureSelfSigned.
+	Generated bool
+}
+
+// EnsureSelfSigned loads the self-signed certificates in dir, creating the CA if it doesn't
+// exist and issuing a new server certificate if there is none (or it can't be loaded), it
+// expires within 30 days or it doesn't cover every one of hosts (names or IP addresses).
+func EnsureSelfSigned(dir string, hosts []string) (*SelfSigned, error) {
+	s := &SelfSigned{
+		Dir:      dir,
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	caKeyFile := filepath.Join(dir, "ca-key.pem")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	ca, caKey, err := loadPair(s.CAFile, caKeyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		ca, caKey, err = createCA(s.CAFile, caKeyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ca: %w", err)
+	}
+	s.CA = ca
+
+	cert, _, err := loadPair(s.CertFile, s.KeyFile)
+	if err == nil && cert.CheckSignatureFrom(ca) == nil && time.Until(cert.NotAfter) > renewBefore && covers(cert, hosts) {
+		s.Cert = cert
+		return s, nil
+	}
+	s.Cert, err = createServerCert(s.CertFile, s.KeyFile, ca, caKey, hosts)
+	if err != nil {
+		return nil, fmt.Errorf("server certificate: %w", err)
+	}
+	s.Generated = true
+	return s, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of cert as colon separated hex, the way
+// browsers show it.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// LocalHosts returns the host name of the machine, localhost and the addresses of its
+// network interfaces.
+func LocalHosts() []string {
+	hosts := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil {
+		hosts = append(hosts, hostname)
+		if !strings.Contains(hostname, ".") {
+			hosts = append(hosts, hostname+".local")
+		}
+	}
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLinkLocalUnicast() {
+			hosts = append(hosts, ipnet.IP.String())
+		}
+	}
+	return hosts
+}
+
+func covers(cert *x509.Certificate, hosts []string) bool {
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			if !slices.ContainsFunc(cert.IPAddresses, ip.Equal) {
+				return false
+			}
+		} else if !slices.Contains(cert.DNSNames, host) {
+			return false
+		}
+	}
+	return true
+}
+
+func createCA(certFile, keyFile string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "system CA " + hostname, Organization: []string{"system"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	return create(certFile, keyFile, template, nil, nil)
+}
+
+func createServerCert(certFile, keyFile string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, hosts []string) (*x509.Certificate, error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0], Organization: []string{"system"}},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(serverValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	cert, _, err := create(certFile, keyFile, template, ca, caKey)
+	return cert, err
+}
+
+// create generates a key and a certificate from template signed by parent (or self-signed
+// if parent is nil) and writes both as PEM.
+func create(certFile, keyFile string, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	// write the key first so that a reloader never sees a certificate without its key
+	if err := writePEM(keyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(certFile, "CERTIFICATE", der, 0644); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+func loadPair(certFile, keyFile string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certDER, err := readPEM(certFile, "CERTIFICATE")
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := readPEM(keyFile, "EC PRIVATE KEY")
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyDER)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s: no %s PEM block", path, blockType)
+	}
+	return block.Bytes, nil
+}
+
+// writePEM writes the file atomically (to a temporary file that is renamed).
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
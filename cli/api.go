@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// api makes requests to the /api/v1 endpoints of a server.
+type api struct {
+	base   string
+	server *Server
+	http   *http.Client
+}
+
+// newAPI returns a client for server, which is an http(s):// URL or unix:// followed by
+// the path of a unix socket.
+func newAPI(server string, creds *Server) (*api, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	base := strings.TrimRight(server, "/")
+	if socket, ok := strings.CutPrefix(server, "unix://"); ok {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		}
+		base = "http://localhost"
+	} else if !strings.HasPrefix(server, "http://") && !strings.HasPrefix(server, "https://") {
+		return nil, fmt.Errorf("server %q must start with http://, https:// or unix://", server)
+	}
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: creds.Insecure}
+	if creds.CAFile != "" {
+		pem, err := os.ReadFile(creds.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", creds.CAFile)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return &api{base: base + "/api/v1", server: creds, http: &http.Client{Transport: transport}}, nil
+}
+
+// request sends a request with body encoded as JSON (if not nil) and returns the response
+// if its status is successful. otherwise the error sent by the server is returned.
+func (a *api) request(method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, a.base+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if a.server.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.server.Token)
+	} else if a.server.Session != "" {
+		req.AddCookie(&http.Cookie{Name: "auth_token", Value: a.server.Session})
+	}
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			e.Error += " (run `system login`)"
+		}
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			e.Error += ", retry after " + retryAfter + " seconds"
+		}
+		return nil, errors.New(e.Error)
+	}
+	return resp, nil
+}
+
+// do sends a request and decodes the JSON response into v (if not nil).
+func (a *api) do(method, path string, body, v any) error {
+	resp, err := a.request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (a *api) get(path string, v any) error {
+	return a.do(http.MethodGet, path, nil, v)
+}
+
+// poll calls fn with fresh data from path every interval until fn returns false or an
+// error occurs.
+func (a *api) poll(path string, interval time.Duration, v any, fn func() bool) error {
+	for {
+		if err := a.get(path, v); err != nil {
+			return err
+		}
+		if !fn() {
+			return nil
+		}
+		time.Sleep(interval)
+	}
+}
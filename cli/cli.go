@@ -0,0 +1,145 @@
+// Package cli is a command-line client for the api of a running server.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type command struct {
+	usage string
+	help  string
+	run   func(e *env, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	// assigned in init because the help command refers to commands
+	commands = map[string]command{
+		"login":    {"login [--user name | --token] [--ca-file ca.pem] [--insecure]", "log in with a TOTP code or an api token", login},
+		"logout":   {"logout", "end the session and forget the credentials", logout},
+		"whoami":   {"whoami", "show the user, role and permissions", whoami},
+		"info":     {"info", "show system information", info},
+		"top":      {"top [--interval 2s] [--limit 20] [--sort cpu]", "show the busiest processes, refreshed", top},
+		"ps":       {"ps [--sort cpu|mem|pid|name|threads] [--limit n]", "list processes", ps},
+		"services": {"services [--status running]", "list services", services},
+		"logs":     {"logs [-u unit] [-f] [-n lines] [--since 1h] [--boot]", "show system or service logs", logs},
+		"signal":   {"signal <pid> <signal>", "send a signal (TERM, KILL, STOP, CONT, QUIT) to a process", signal},
+		"service":  {"service <start|stop|restart|status> <name>", "control a service", service},
+		"reboot":   {"reboot [--at 03:00 | --in 10m] [--message text] [--yes]", "reboot now or at a time", power("reboot")},
+		"shutdown": {"shutdown [--at 03:00 | --in 10m] [--message text] [--yes]", "shut down now or at a time", power("shutdown")},
+		"help":     {"help", "show this help", func(e *env, _ []string) error { usage(e.stdout); return nil }},
+	}
+}
+
+// IsCommand reports whether name is a client command, as opposed to running the server.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// env is what a command runs with.
+type env struct {
+	flags  *flag.FlagSet
+	server string
+	json   bool
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	config *Config
+}
+
+// Run runs the client command in args[0] and returns the exit code.
+func Run(args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(os.Stderr)
+		return 2
+	}
+	e := &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	e.flags = flag.NewFlagSet(args[0], flag.ContinueOnError)
+	e.flags.StringVar(&e.server, "server", os.Getenv("SYSTEM_SERVER"), "server url (https://host:port or unix:///path/to/socket), defaults to the last one logged in to")
+	e.flags.BoolVar(&e.json, "json", false, "print json instead of tables")
+	e.flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: system %s\n\n%s\n\n", cmd.usage, cmd.help)
+		e.flags.PrintDefaults()
+	}
+	var err error
+	if e.config, err = loadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "error: load client config:", err)
+		return 1
+	}
+	if err := cmd.run(e, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+// parse parses the flags of a command (which may come after its arguments) and returns its
+// arguments.
+func (e *env) parse(args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := e.flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = e.flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// api returns a client for the selected server.
+func (e *env) api() (*api, error) {
+	server := e.server
+	if server == "" {
+		server = e.config.Server
+	}
+	if server == "" {
+		return nil, errors.New("no server, log in with `system login --server https://host:port` or pass --server")
+	}
+	creds := e.config.Servers[server]
+	if creds == nil {
+		creds = &Server{}
+	}
+	return newAPI(server, creds)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: system [-config file]      run the server")
+	fmt.Fprintln(w, "       system <command> [flags]   talk to a running server")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].help)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "every command takes --server and --json. run `system <command> -h` for its flags.")
+}
+
+// confirm asks a yes/no question on stdin.
+func (e *env) confirm(question string) bool {
+	fmt.Fprintf(e.stdout, "%s [y/N] ", question)
+	var answer string
+	fmt.Fscanln(e.stdin, &answer)
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+}
@@ -0,0 +1,450 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiredkangaroo/system/system"
+)
+
+type authInfo struct {
+	RequiresAuth bool     `json:"requires_auth"`
+	User         string   `json:"user"`
+	Role         string   `json:"role"`
+	Permissions  []string `json:"permissions"`
+}
+
+func login(e *env, args []string) error {
+	user := e.flags.String("user", "", "user to log in as, the admin of SYSTEM_TOTP_SECRET if empty")
+	useToken := e.flags.Bool("token", false, "log in with an api token instead of a TOTP code, read from SYSTEM_API_TOKEN or asked for")
+	caFile := e.flags.String("ca-file", "", "CA certificate to trust, e.g. the ca.pem of a self-signed server")
+	insecure := e.flags.Bool("insecure", false, "don't verify the server's certificate")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	if e.server == "" {
+		e.server = e.config.Server
+	}
+	creds := &Server{Insecure: *insecure}
+	if old := e.config.Servers[e.server]; old != nil && *caFile == "" && !*insecure {
+		creds.CAFile, creds.Insecure = old.CAFile, old.Insecure
+	}
+	if *caFile != "" {
+		abs, err := filepath.Abs(*caFile)
+		if err != nil {
+			return err
+		}
+		creds.CAFile = abs
+	}
+	e.config.Servers[e.server] = creds
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+
+	// secrets are never taken as arguments, which other users can see in ps
+	if *useToken {
+		token := os.Getenv("SYSTEM_API_TOKEN")
+		if token == "" {
+			if token, err = e.prompt("API token: "); err != nil {
+				return err
+			}
+		}
+		creds.Token = token
+	} else {
+		code, err := e.prompt("TOTP code: ")
+		if err != nil {
+			return err
+		}
+		resp, err := a.request(http.MethodPost, "/auth/login", map[string]string{"user": *user, "code": code})
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		i := slices.IndexFunc(resp.Cookies(), func(c *http.Cookie) bool { return c.Name == "auth_token" })
+		if i == -1 {
+			return errors.New("the server did not start a session")
+		}
+		creds.Session = resp.Cookies()[i].Value
+	}
+
+	var me authInfo
+	if err := a.get("/auth", &me); err != nil {
+		return err
+	}
+	e.config.Server = e.server
+	if err := e.config.save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "logged in to %s as %s (%s)\n", e.server, me.User, me.Role)
+	return nil
+}
+
+// prompt asks for a line on stdin.
+func (e *env) prompt(label string) (string, error) {
+	fmt.Fprint(e.stdout, label)
+	line, err := bufio.NewReader(e.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func logout(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	if a.server.Session != "" {
+		if err := a.do(http.MethodPost, "/auth/logout", nil, nil); err != nil {
+			fmt.Fprintln(e.stderr, "warning: end session:", err)
+		}
+	}
+	for server, creds := range e.config.Servers {
+		if creds == a.server {
+			delete(e.config.Servers, server)
+		}
+	}
+	return e.config.save()
+}
+
+func whoami(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	var me authInfo
+	if err := a.get("/auth", &me); err != nil {
+		return err
+	}
+	if e.json {
+		return e.printJSON(me)
+	}
+	return e.printFields([][2]string{
+		{"user", me.User},
+		{"role", me.Role},
+		{"permissions", strings.Join(me.Permissions, ", ")},
+		{"auth required", strconv.FormatBool(me.RequiresAuth)},
+	})
+}
+
+func info(e *env, args []string) error {
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	var info system.SystemInfo
+	if err := a.get("/info", &info); err != nil {
+		return err
+	}
+	if e.json {
+		return e.printJSON(info)
+	}
+	fields := [][2]string{
+		{"hostname", info.Hostname},
+		{"os", strings.TrimSpace(info.OS + " " + info.OSRelease)},
+		{"cpu", fmt.Sprintf("%s (%d cores, %s)", info.CPU, info.NumCPU, info.Arch)},
+		{"cpu usage", fmt.Sprintf("%.1f%%", info.CPU_Usage)},
+	}
+	if info.CPU_Temp > 0 {
+		fields = append(fields, [2]string{"cpu temp", fmt.Sprintf("%.1f°C", info.CPU_Temp)})
+	}
+	fields = append(fields,
+		[2]string{"memory", formatUsage(info.MemoryUsed, info.Memory)},
+		[2]string{"storage", formatUsage(info.StorageUsed, info.StorageCapacity)},
+	)
+	if info.HasBattery {
+		fields = append(fields, [2]string{"battery", fmt.Sprintf("%.0f%% %s", info.BatteryPercent, info.BatteryStatus)})
+	}
+	fields = append(fields,
+		[2]string{"uptime", formatUptime(info.Uptime)},
+		[2]string{"processes", strconv.Itoa(len(info.Processes))},
+		[2]string{"services", strconv.Itoa(len(info.Services))},
+	)
+	return e.printFields(fields)
+}
+
+// processFlags adds the flags shared by ps and top.
+func processFlags(e *env, limit int) (sortBy *string, n *int) {
+	sortBy = e.flags.String("sort", "cpu", "sort by cpu, mem, io, pid, name or threads")
+	n = e.flags.Int("limit", limit, "number of processes to show, 0 for all")
+	return sortBy, n
+}
+
+func ps(e *env, args []string) error {
+	sortBy, limit := processFlags(e, 0)
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	var info system.SystemInfo
+	if err := a.get("/info?fields=processes", &info); err != nil {
+		return err
+	}
+	processes, err := sortProcesses(info.Processes, *sortBy, *limit)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.printJSON(processes)
+	}
+	return e.printProcesses(processes)
+}
+
+func top(e *env, args []string) error {
+	sortBy, limit := processFlags(e, 20)
+	interval := e.flags.Duration("interval", 2*time.Second, "how often to refresh")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	// the static info doesn't change, so only the fields shown are polled
+	var static system.StaticInfo
+	if err := a.get("/info/static", &static); err != nil {
+		return err
+	}
+	var info system.DynamicInfo
+	fields := system.FieldCPUUsage | system.FieldMemoryUsed | system.FieldUptime | system.FieldProcesses
+	var sortErr error
+	err = a.poll("/info?fields="+fields.String(), *interval, &info, func() bool {
+		var processes []system.Process
+		if processes, sortErr = sortProcesses(info.Processes, *sortBy, *limit); sortErr != nil {
+			return false
+		}
+		if e.json {
+			// one line per refresh
+			return e.printJSONLine(processes) == nil
+		}
+		fmt.Fprint(e.stdout, "\x1b[H\x1b[2J") // clear the screen
+		fmt.Fprintf(e.stdout, "%s  up %s  cpu %.1f%%  mem %s  %d processes\n\n",
+			static.Hostname, formatUptime(info.Uptime), info.CPU_Usage, formatUsage(info.MemoryUsed, static.Memory), len(info.Processes))
+		return e.printProcesses(processes) == nil
+	})
+	if err != nil {
+		return err
+	}
+	return sortErr
+}
+
+func services(e *env, args []string) error {
+	status := e.flags.String("status", "", "only show services with this status, e.g. running")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	var info system.SystemInfo
+	if err := a.get("/info?fields=services", &info); err != nil {
+		return err
+	}
+	list := slices.DeleteFunc(info.Services, func(s system.Service) bool {
+		return *status != "" && s.Status != *status
+	})
+	slices.SortFunc(list, func(a, b system.Service) int { return strings.Compare(a.Name, b.Name) })
+	if e.json {
+		return e.printJSON(list)
+	}
+	rows := make([][]string, 0, len(list))
+	for _, s := range list {
+		rows = append(rows, []string{s.Name, s.Status, s.Description})
+	}
+	return e.printTable([]string{"NAME", "STATUS", "DESCRIPTION"}, rows)
+}
+
+func logs(e *env, args []string) error {
+	unit := e.flags.String("u", "", "service to show the logs of, the whole system if empty")
+	follow := e.flags.Bool("f", false, "keep printing new entries")
+	lines := e.flags.Int("n", 0, "only show the last lines")
+	since := e.flags.String("since", "", "only show entries since a time (15:04, 2006-01-02 15:04) or a duration ago (1h)")
+	boot := e.flags.Bool("boot", false, "only show entries from this boot")
+	if _, err := e.parse(args); err != nil {
+		return err
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	if *since != "" {
+		t, err := parseTime(*since, true)
+		if err != nil {
+			return err
+		}
+		query.Set("since", strconv.FormatInt(t.Unix(), 10))
+	}
+	if *lines > 0 {
+		query.Set("lines", strconv.Itoa(*lines))
+	}
+	if *follow {
+		query.Set("follow", "true")
+	}
+	if *boot {
+		query.Set("this_boot_only", "true")
+	}
+	path := "/system/logs"
+	if *unit != "" {
+		path = "/service/" + url.PathEscape(*unit) + "/logs"
+	}
+	resp, err := a.request(http.MethodGet, path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(e.stdout, resp.Body)
+	return err
+}
+
+func signal(e *env, args []string) error {
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		e.flags.Usage()
+		return errors.New("expected a pid and a signal")
+	}
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid pid %q", args[0])
+	}
+	sig := strings.ToUpper(args[1])
+	if !strings.HasPrefix(sig, "SIG") {
+		sig = "SIG" + sig
+	}
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	return a.do(http.MethodPost, fmt.Sprintf("/process/%d/signal/%s", pid, sig), nil, nil)
+}
+
+func service(e *env, args []string) error {
+	args, err := e.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		e.flags.Usage()
+		return errors.New("expected an action and a service name")
+	}
+	action, name := args[0], url.PathEscape(args[1])
+	a, err := e.api()
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "start", "stop", "restart":
+		return a.do(http.MethodPatch, "/service/"+name+"/"+action, nil, nil)
+	case "status":
+		var s system.Service
+		if err := a.get("/service/"+name, &s); err != nil {
+			return err
+		}
+		if e.json {
+			return e.printJSON(s)
+		}
+		return e.printFields([][2]string{{"name", s.Name}, {"status", s.Status}, {"description", s.Description}})
+	default:
+		return fmt.Errorf("unknown action %q, expected start, stop, restart or status", action)
+	}
+}
+
+// power returns the command for a power action that runs now or is scheduled.
+func power(action system.PowerAction) func(e *env, args []string) error {
+	return func(e *env, args []string) error {
+		at := e.flags.String("at", "", "when to do it (15:04, 2006-01-02 15:04 or RFC 3339)")
+		in := e.flags.Duration("in", 0, "do it after this long, e.g. 10m")
+		message := e.flags.String("message", "", "broadcast to logged in users when scheduling")
+		yes := e.flags.Bool("yes", false, "don't ask for confirmation")
+		if _, err := e.parse(args); err != nil {
+			return err
+		}
+		a, err := e.api()
+		if err != nil {
+			return err
+		}
+		var when time.Time
+		switch {
+		case *at != "":
+			if when, err = parseTime(*at, false); err != nil {
+				return err
+			}
+		case *in > 0:
+			when = time.Now().Add(*in)
+		default:
+			if !*yes && !e.confirm(fmt.Sprintf("%s the server now?", action)) {
+				return errors.New("cancelled")
+			}
+			return a.do(http.MethodPost, "/system/"+string(action), nil, nil)
+		}
+		var scheduled system.ScheduledPowerAction
+		err = a.do(http.MethodPost, "/system/power/schedule", map[string]any{
+			"action":  action,
+			"at":      when.Unix(),
+			"message": *message,
+		}, &scheduled)
+		if err != nil {
+			return err
+		}
+		if e.json {
+			return e.printJSON(scheduled)
+		}
+		fmt.Fprintf(e.stdout, "%s scheduled at %s (id %s)\n", scheduled.Action, scheduled.At.Local().Format(time.DateTime), scheduled.ID)
+		return nil
+	}
+}
+
+// parseTime parses a time of day (the next one, or the last one if past is true), a date
+// and time, an RFC 3339 timestamp or, if past is true, a duration ago.
+func parseTime(s string, past bool) (time.Time, error) {
+	if past {
+		if d, err := time.ParseDuration(s); err == nil {
+			return time.Now().Add(-d), nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t, nil
+	}
+	clock, err := time.ParseInLocation("15:04", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	now := time.Now()
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	if past && t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	} else if !past && t.Before(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
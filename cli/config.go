@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/tiredkangaroo/system/auth"
+)
+
+// Config is the client configuration, with the credentials of every server logged in to.
+type Config struct {
+	Server  string             `toml:"server"` // used when --server is not given
+	Servers map[string]*Server `toml:"servers"`
+}
+
+type Server struct {
+	Token    string `toml:"token,omitempty"`   // api token
+	Session  string `toml:"session,omitempty"` // auth_token cookie from logging in with a TOTP code
+	CAFile   string `toml:"ca_file,omitempty"` // CA to trust, e.g. the ca.pem of a self-signed server
+	Insecure bool   `toml:"insecure,omitempty"`
+}
+
+// configPath returns SYSTEM_CLIENT_CONFIG or client.toml in the user's config directory.
+func configPath() (string, error) {
+	if path := os.Getenv("SYSTEM_CLIENT_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "system", "client.toml"), nil
+}
+
+func loadConfig() (*Config, error) {
+	c := &Config{Servers: map[string]*Server{}}
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := toml.DecodeFile(path, c); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if c.Servers == nil {
+		c.Servers = map[string]*Server{}
+	}
+	return c, nil
+}
+
+// save writes the configuration atomically, readable only by the user since it holds
+// credentials.
+func (c *Config) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return err
+	}
+	return auth.WriteFileAtomic(path, buf.Bytes())
+}
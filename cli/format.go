@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tiredkangaroo/system/system"
+)
+
+func (e *env) printJSON(v any) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (e *env) printJSONLine(v any) error {
+	return json.NewEncoder(e.stdout).Encode(v)
+}
+
+func (e *env) printTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func (e *env) printFields(fields [][2]string) error {
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s\t%s\n", f[0], f[1])
+	}
+	return w.Flush()
+}
+
+func (e *env) printProcesses(processes []system.Process) error {
+	rows := make([][]string, 0, len(processes))
+	for _, p := range processes {
+		rows = append(rows, []string{
+			strconv.Itoa(int(p.PID)),
+			p.Name,
+			p.Status,
+			strconv.Itoa(int(p.Threads)),
+			formatPercent(p.CPUPercent),
+			formatPercent(float64(p.MemoryPercent)),
+			formatRate(p.DiskReadRate),
+			formatRate(p.DiskWriteRate),
+		})
+	}
+	return e.printTable([]string{"PID", "NAME", "STATUS", "THREADS", "CPU%", "MEM%", "READ/S", "WRITE/S"}, rows)
+}
+
+// sortProcesses sorts processes (busiest first for cpu, mem, io and threads) and returns the
+// first limit of them, or all if limit is 0.
+func sortProcesses(processes []system.Process, by string, limit int) ([]system.Process, error) {
+	var compare func(a, b system.Process) int
+	switch by {
+	case "cpu":
+		compare = func(a, b system.Process) int { return cmp.Compare(b.CPUPercent, a.CPUPercent) }
+	case "mem":
+		compare = func(a, b system.Process) int { return cmp.Compare(b.MemoryPercent, a.MemoryPercent) }
+	case "io":
+		compare = func(a, b system.Process) int {
+			return cmp.Compare(b.DiskReadRate+b.DiskWriteRate, a.DiskReadRate+a.DiskWriteRate)
+		}
+	case "threads":
+		compare = func(a, b system.Process) int { return cmp.Compare(b.Threads, a.Threads) }
+	case "pid":
+		compare = func(a, b system.Process) int { return cmp.Compare(a.PID, b.PID) }
+	case "name":
+		compare = func(a, b system.Process) int { return strings.Compare(a.Name, b.Name) }
+	default:
+		return nil, fmt.Errorf("unknown sort %q, expected cpu, mem, io, pid, name or threads", by)
+	}
+	slices.SortStableFunc(processes, compare)
+	if limit > 0 && len(processes) > limit {
+		processes = processes[:limit]
+	}
+	return processes, nil
+}
+
+// formatBytes formats a size in bytes with binary units, e.g. 1.5 GiB.
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// formatPercent formats a percentage, - if unknown.
+func formatPercent(percent float64) string {
+	if percent < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", percent)
+}
+
+// formatRate formats bytes per second, - if unknown.
+func formatRate(bytesPerSecond float64) string {
+	if bytesPerSecond < 0 {
+		return "-"
+	}
+	return formatBytes(uint64(bytesPerSecond))
+}
+
+func formatUsage(used, total uint64) string {
+	if total == 0 {
+		return formatBytes(used)
+	}
+	return fmt.Sprintf("%s / %s (%.0f%%)", formatBytes(used), formatBytes(total), float64(used)/float64(total)*100)
+}
+
+// formatUptime formats seconds as e.g. 3d 4h 5m.
+func formatUptime(seconds uint64) string {
+	d, h, m := seconds/86400, seconds%86400/3600, seconds%3600/60
+	if d > 0 {
+		return fmt.Sprintf("%dd %dh %dm", d, h, m)
+	}
+	if h > 0 {
+		return fmt.Sprintf("%dh %dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}
@@ -0,0 +1,16 @@
+// Package client is a Go client of the REST api, generated from the OpenAPI specification
+// in the openapi package. for example:
+//
+//	c, err := client.NewClientWithResponses("https://server:8080/api/v1", client.WithRequestEditorFn(
+//		func(ctx context.Context, req *http.Request) error {
+//			req.Header.Set("Authorization", "Bearer "+token)
+//			return nil
+//		}))
+//	resp, err := c.GetInfoWithResponse(ctx, nil)
+//
+// to manage a host through a hub, use https://hub:8080/api/v1/fleet/hosts/<name>/api/v1 as
+// the server.
+package client
+
+// requires oapi-codegen (github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen) in $PATH.
+//go:generate oapi-codegen -config oapi-codegen.yaml ../openapi/openapi.json
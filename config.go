@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/tiredkangaroo/system/config"
+)
+
+var configFile = flag.String("config", "", "path to a TOML configuration file, reloaded on SIGHUP")
+
+var currentConfig atomic.Pointer[config.Config]
+
+// conf returns the current configuration. callers should not hold on to it, so that
+// reloaded settings take effect.
+func conf() *config.Config {
+	return currentConfig.Load()
+}
+
+func configInit() error {
+	c, err := config.Load(*configFile)
+	if err != nil {
+		return err
+	}
+	currentConfig.Store(c)
+	applyConfig(c)
+	return nil
+}
+
+// reloadConfigOnSIGHUP reloads the configuration whenever SIGHUP is received. an invalid
+// configuration is logged and the current one is kept.
+func reloadConfigOnSIGHUP(onReload func(c *config.Config)) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for range ch {
+			c, err := config.Load(*configFile)
+			if err != nil {
+				slog.Error("reload config, keeping the current configuration", "error", err)
+				continue
+			}
+			if settings := conf().RestartRequired(c); len(settings) > 0 {
+				slog.Warn("some changed settings only take effect after a restart", "settings", settings)
+			}
+			currentConfig.Store(c)
+			applyConfig(c)
+			onReload(c)
+			slog.Info("configuration reloaded")
+		}
+	}()
+}
+
+func applyConfig(c *config.Config) {
+	if c.Debug {
+		slog.SetLogLoggerLevel(slog.LevelDebug)
+	} else {
+		slog.SetLogLoggerLevel(slog.LevelInfo)
+	}
+}
+
+// stateDir returns the directory where the server persists its state (audit log, users, etc.).
+func stateDir() string {
+	if dir := conf().StateDir; dir != "" {
+		return dir
+	}
+	if os.Geteuid() == 0 {
+		return "/var/lib/system"
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "system")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "system-state"
+	}
+	return filepath.Join(home, ".local", "state", "system")
+}
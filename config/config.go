@@ -0,0 +1,497 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/tiredkangaroo/system/auth"
+)
+
+// Config is the configuration of the server. It is loaded from an optional TOML file,
+// then overridden by environment variables.
+type Config struct {
+	ListenAddr string           `toml:"listen_addr"` // env: LISTEN_ADDR, a single TCP listener used when Listeners is empty
+	Listeners  []ListenerConfig `toml:"listeners"`
+	Debug      bool             `toml:"debug"`     // env: DEBUG
+	StateDir   string           `toml:"state_dir"` // env: SYSTEM_STATE_DIR
+
+	ShutdownTimeout Duration `toml:"shutdown_timeout"` // how long in-flight requests get to finish on shutdown
+
+	TLS      TLSConfig      `toml:"tls"`
+	Auth     AuthConfig     `toml:"auth"`
+	Refresh  RefreshConfig  `toml:"refresh"`
+	CORS     CORSConfig     `toml:"cors"`
+	Features FeaturesConfig `toml:"features"`
+	Policy   PolicyConfig   `toml:"policy"`
+	Audit    AuditConfig    `toml:"audit"`
+	Hub      HubConfig      `toml:"hub"`
+	Agent    AgentConfig    `toml:"agent"`
+	GRPC     GRPCConfig     `toml:"grpc"`
+}
+
+type ListenerConfig struct {
+	Network string `toml:"network"` // "tcp" (dual-stack), "tcp4", "tcp6" or "unix"
+	Address string `toml:"address"` // host:port, or the path of a unix socket
+
+	// unix sockets only
+	Mode  string `toml:"mode"`  // octal file mode, "0660" by default
+	Owner string `toml:"owner"` // user name or uid
+	Group string `toml:"group"` // group name or gid
+}
+
+type TLSConfig struct {
+	CertFile   string   `toml:"cert_file"`   // env: TLS_CERT_FILE
+	KeyFile    string   `toml:"key_file"`    // env: TLS_KEY_FILE
+	SelfSigned bool     `toml:"self_signed"` // env: SYSTEM_TLS_SELF_SIGNED, generate a CA and certificate in the state directory
+	Hosts      []string `toml:"hosts"`       // extra names and IPs for the self-signed certificate
+
+	ClientCAFile string             `toml:"client_ca_file"` // env: SYSTEM_TLS_CLIENT_CA_FILE, enables client certificates
+	ClientAuth   string             `toml:"client_auth"`    // "optional" (default) or "required"
+	ClientCerts  []ClientCertConfig `toml:"client_certs"`
+}
+
+// ClientCertConfig maps client certificates to a user. Match is compared with the common
+// name of the subject and every DNS, email and URI SAN of the certificate. If Role is empty,
+// the user must exist and their role is used.
+type ClientCertConfig struct {
+	Match string `toml:"match"`
+	User  string `toml:"user"`
+	Role  string `toml:"role"`
+}
+
+type AuthConfig struct {
+	TOTPSecret      string   `toml:"totp_secret"`      // env: SYSTEM_TOTP_SECRET
+	JWTSecret       string   `toml:"jwt_secret"`       // env: SYSTEM_JWT_SECRET
+	UsersFile       string   `toml:"users_file"`       // env: SYSTEM_USERS_FILE
+	TokensFile      string   `toml:"tokens_file"`      // env: SYSTEM_TOKENS_FILE
+	SessionsFile    string   `toml:"sessions_file"`    // env: SYSTEM_SESSIONS_FILE
+	SessionLifetime Duration `toml:"session_lifetime"` // env: SYSTEM_SESSION_LIFETIME
+
+	LocalUsers []LocalUserConfig `toml:"local_users"`
+}
+
+// LocalUserConfig maps clients connecting over a unix socket, identified by their peer
+// credentials, to a user. Exactly one of UnixUser and UnixGroup (names or numeric ids) is
+// set. If Role is empty, the user must exist and their role is used.
+type LocalUserConfig struct {
+	UnixUser  string `toml:"unix_user"`
+	UnixGroup string `toml:"unix_group"`
+	User      string `toml:"user"`
+	Role      string `toml:"role"`
+}
+
+type RefreshConfig struct {
+	MetricsInterval   Duration `toml:"metrics_interval"`   // how often cpu, memory, storage and battery usage are collected
+	ProcessesInterval Duration `toml:"processes_interval"` // how often processes are collected
+	ServicesInterval  Duration `toml:"services_interval"`  // how often services are collected
+	WebsocketInterval Duration `toml:"websocket_interval"` // how often system info is pushed to websockets, at most
+}
+
+// CORSConfig controls which browser origins may call the API with the user's cookie. The
+// server's own origin is always allowed.
+type CORSConfig struct {
+	AllowOrigins []string `toml:"allow_origins"` // env: SYSTEM_CORS_ALLOW_ORIGINS (comma separated)
+	MaxAge       Duration `toml:"max_age"`
+	Permissive   bool     `toml:"permissive"` // env: SYSTEM_CORS_PERMISSIVE, allows every origin, for development only
+}
+
+// HostedFrontendOrigin is the origin of the hosted web interface.
+const HostedFrontendOrigin = "https://tiredkangaroo.github.io"
+
+type FeaturesConfig struct {
+	Logs         bool `toml:"logs"`          // system and service logs
+	Signals      bool `toml:"signals"`       // sending signals to processes
+	Services     bool `toml:"services"`      // starting, stopping and restarting services
+	PowerActions bool `toml:"power_actions"` // shutdown, reboot, sleep and scheduling them
+	WebUI        bool `toml:"web_ui"`        // the web interface embedded in the binary, served at /
+}
+
+// PolicyConfig restricts privileged actions beyond what roles allow.
+type PolicyConfig struct {
+	RequireRoot       bool     `toml:"require_root"`       // refuse privileged actions unless running as root
+	AllowedSignals    []string `toml:"allowed_signals"`    // signals that may be sent to processes
+	ProtectedServices []string `toml:"protected_services"` // services that may not be stopped or restarted
+}
+
+type AuditConfig struct {
+	File       string `toml:"file"`        // env: SYSTEM_AUDIT_LOG_FILE
+	Journal    bool   `toml:"journal"`     // env: SYSTEM_AUDIT_JOURNAL
+	MaxSize    int64  `toml:"max_size"`    // bytes after which the audit log is rotated
+	MaxBackups int    `toml:"max_backups"` // rotated audit logs to keep
+}
+
+// HubConfig turns the server into a hub that polls registered agents for a fleet-wide view.
+type HubConfig struct {
+	Enabled      bool     `toml:"enabled"`       // env: SYSTEM_HUB
+	AgentsFile   string   `toml:"agents_file"`   // env: SYSTEM_HUB_AGENTS_FILE
+	PollInterval Duration `toml:"poll_interval"` // how often the info of every agent is fetched
+	Timeout      Duration `toml:"timeout"`       // of polls and proxied requests to agents
+
+	Alerts HubAlertsConfig `toml:"alerts"`
+}
+
+// HubAlertsConfig are the usage percentages at which a host is alerted on, 0 disables one.
+type HubAlertsConfig struct {
+	CPUPercent     float64 `toml:"cpu_percent"`
+	MemoryPercent  float64 `toml:"memory_percent"`
+	StoragePercent float64 `toml:"storage_percent"`
+}
+
+// AgentConfig makes the server connect to a hub and serve the hub's requests over that
+// connection, for servers the hub can't reach.
+type AgentConfig struct {
+	HubURL       string   `toml:"hub_url"`       // env: SYSTEM_AGENT_HUB_URL, e.g. https://hub:8080
+	Name         string   `toml:"name"`          // env: SYSTEM_AGENT_NAME, the name the agent is registered with
+	Token        string   `toml:"token"`         // env: SYSTEM_AGENT_TOKEN, returned when the agent is registered
+	CAFile       string   `toml:"ca_file"`       // CA to verify the hub's certificate with
+	Insecure     bool     `toml:"insecure"`      // skip verifying the hub's certificate
+	Role         string   `toml:"role"`          // role of the requests from the hub
+	PushInterval Duration `toml:"push_interval"` // how often system info is sent to the hub
+}
+
+// GRPCConfig enables the gRPC api, served next to the REST api with the same TLS
+// configuration.
+type GRPCConfig struct {
+	Address string `toml:"address"` // env: SYSTEM_GRPC_ADDR, e.g. ":9090", disabled if empty
+}
+
+// Signals lists every signal name that can be allowed in PolicyConfig.AllowedSignals.
+var Signals = []string{"SIGKILL", "SIGTERM", "SIGSTOP", "SIGCONT", "SIGQUIT"}
+
+func Default() *Config {
+	return &Config{
+		ListenAddr:      ":0",
+		ShutdownTimeout: Duration(10 * time.Second),
+		Auth: AuthConfig{
+			SessionLifetime: Duration(24 * time.Hour),
+		},
+		Refresh: RefreshConfig{
+			MetricsInterval:   Duration(time.Second),
+			ProcessesInterval: Duration(5 * time.Second),
+			ServicesInterval:  Duration(10 * time.Second),
+			WebsocketInterval: Duration(time.Second),
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{HostedFrontendOrigin},
+			MaxAge:       Duration(time.Hour),
+		},
+		Features: FeaturesConfig{
+			Logs:         true,
+			Signals:      true,
+			Services:     true,
+			PowerActions: true,
+			WebUI:        true,
+		},
+		Policy: PolicyConfig{
+			RequireRoot:    true,
+			AllowedSignals: slices.Clone(Signals),
+		},
+		Audit: AuditConfig{
+			MaxSize:    10 * 1024 * 1024,
+			MaxBackups: 5,
+		},
+		Agent: AgentConfig{
+			Role:         string(auth.RoleOperator),
+			PushInterval: Duration(5 * time.Second),
+		},
+		Hub: HubConfig{
+			PollInterval: Duration(10 * time.Second),
+			Timeout:      Duration(5 * time.Second),
+			Alerts: HubAlertsConfig{
+				CPUPercent:     90,
+				MemoryPercent:  90,
+				StoragePercent: 90,
+			},
+		},
+	}
+}
+
+// Load reads the configuration file at path (if path is not empty), applies environment
+// variable overrides and validates the result.
+func Load(path string) (*Config, error) {
+	c := Default()
+	if path != "" {
+		md, err := toml.DecodeFile(path, c)
+		if err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown keys in config file %s: %v", path, undecoded)
+		}
+	}
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) applyEnv() error {
+	envString := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = v
+		}
+	}
+	envBool := func(name string, dst *bool) error {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q, expected true or false", name, v)
+		}
+		*dst = b
+		return nil
+	}
+	envString("LISTEN_ADDR", &c.ListenAddr)
+	envString("SYSTEM_STATE_DIR", &c.StateDir)
+	envString("TLS_CERT_FILE", &c.TLS.CertFile)
+	envString("TLS_KEY_FILE", &c.TLS.KeyFile)
+	envString("SYSTEM_TLS_CLIENT_CA_FILE", &c.TLS.ClientCAFile)
+	envString("SYSTEM_TOTP_SECRET", &c.Auth.TOTPSecret)
+	envString("SYSTEM_JWT_SECRET", &c.Auth.JWTSecret)
+	envString("SYSTEM_USERS_FILE", &c.Auth.UsersFile)
+	envString("SYSTEM_TOKENS_FILE", &c.Auth.TokensFile)
+	envString("SYSTEM_SESSIONS_FILE", &c.Auth.SessionsFile)
+	envString("SYSTEM_AUDIT_LOG_FILE", &c.Audit.File)
+	envString("SYSTEM_HUB_AGENTS_FILE", &c.Hub.AgentsFile)
+	envString("SYSTEM_AGENT_HUB_URL", &c.Agent.HubURL)
+	envString("SYSTEM_AGENT_NAME", &c.Agent.Name)
+	envString("SYSTEM_AGENT_TOKEN", &c.Agent.Token)
+	envString("SYSTEM_GRPC_ADDR", &c.GRPC.Address)
+	if v, ok := os.LookupEnv("SYSTEM_CORS_ALLOW_ORIGINS"); ok {
+		c.CORS.AllowOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				c.CORS.AllowOrigins = append(c.CORS.AllowOrigins, origin)
+			}
+		}
+	}
+	if v := os.Getenv("SYSTEM_SESSION_LIFETIME"); v != "" {
+		if err := c.Auth.SessionLifetime.UnmarshalText([]byte(v)); err != nil {
+			return fmt.Errorf("invalid SYSTEM_SESSION_LIFETIME: %w", err)
+		}
+	}
+	return errors.Join(envBool("DEBUG", &c.Debug), envBool("SYSTEM_AUDIT_JOURNAL", &c.Audit.Journal), envBool("SYSTEM_CORS_PERMISSIVE", &c.CORS.Permissive), envBool("SYSTEM_TLS_SELF_SIGNED", &c.TLS.SelfSigned), envBool("SYSTEM_HUB", &c.Hub.Enabled))
+}
+
+// Validate returns every problem with the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.ListenAddr == "" {
+		c.ListenAddr = ":0"
+	}
+	for i, l := range c.Listeners {
+		switch l.Network {
+		case "tcp", "tcp4", "tcp6":
+			if l.Mode != "" || l.Owner != "" || l.Group != "" {
+				errs = append(errs, fmt.Errorf("listeners[%d]: mode, owner and group are only for unix sockets", i))
+			}
+		case "unix":
+			if !filepath.IsAbs(l.Address) {
+				errs = append(errs, fmt.Errorf("listeners[%d]: unix socket address %q must be an absolute path", i, l.Address))
+			}
+			if _, err := l.FileMode(); err != nil {
+				errs = append(errs, fmt.Errorf("listeners[%d]: invalid mode %q, expected octal like 0660", i, l.Mode))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("listeners[%d]: network %q must be tcp, tcp4, tcp6 or unix", i, l.Network))
+		}
+		if l.Address == "" {
+			errs = append(errs, fmt.Errorf("listeners[%d]: address is required", i))
+		}
+	}
+	for i, m := range c.Auth.LocalUsers {
+		if (m.UnixUser == "") == (m.UnixGroup == "") || m.User == "" {
+			errs = append(errs, fmt.Errorf("auth: local_users[%d] needs user and either unix_user or unix_group", i))
+		}
+		if m.Role != "" && !auth.Role(m.Role).Valid() {
+			errs = append(errs, fmt.Errorf("auth: local_users[%d] has unknown role %q", i, m.Role))
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls: cert_file and key_file must be set together"))
+	}
+	if c.TLS.SelfSigned && c.TLS.CertFile != "" {
+		errs = append(errs, errors.New("tls: self_signed cannot be used with cert_file and key_file"))
+	}
+	if c.TLS.ClientCAFile != "" && c.TLS.CertFile == "" && !c.TLS.SelfSigned {
+		errs = append(errs, errors.New("tls: client_ca_file requires TLS to be enabled"))
+	}
+	if c.TLS.ClientAuth != "" && c.TLS.ClientAuth != "optional" && c.TLS.ClientAuth != "required" {
+		errs = append(errs, fmt.Errorf("tls: client_auth %q must be optional or required", c.TLS.ClientAuth))
+	}
+	if len(c.TLS.ClientCerts) > 0 && c.TLS.ClientCAFile == "" {
+		errs = append(errs, errors.New("tls: client_certs requires client_ca_file"))
+	}
+	for i, m := range c.TLS.ClientCerts {
+		if m.Match == "" || m.User == "" {
+			errs = append(errs, fmt.Errorf("tls: client_certs[%d] needs match and user", i))
+		}
+		if m.Role != "" && !auth.Role(m.Role).Valid() {
+			errs = append(errs, fmt.Errorf("tls: client_certs[%d] has unknown role %q", i, m.Role))
+		}
+	}
+	if c.Auth.TOTPSecret != "" && len(c.Auth.TOTPSecret) < 32 {
+		errs = append(errs, errors.New("auth: totp_secret must be at least 32 characters"))
+	}
+	if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < 32 {
+		errs = append(errs, errors.New("auth: jwt_secret must be at least 32 characters"))
+	}
+	positive := []struct {
+		name string
+		d    Duration
+	}{
+		{"auth: session_lifetime", c.Auth.SessionLifetime},
+		{"refresh: metrics_interval", c.Refresh.MetricsInterval},
+		{"refresh: processes_interval", c.Refresh.ProcessesInterval},
+		{"refresh: services_interval", c.Refresh.ServicesInterval},
+		{"refresh: websocket_interval", c.Refresh.WebsocketInterval},
+		{"shutdown_timeout", c.ShutdownTimeout},
+		{"hub: poll_interval", c.Hub.PollInterval},
+		{"hub: timeout", c.Hub.Timeout},
+		{"agent: push_interval", c.Agent.PushInterval},
+	}
+	for _, p := range positive {
+		if p.d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be a positive duration", p.name))
+		}
+	}
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			errs = append(errs, errors.New(`cors: "*" is not allowed in allow_origins, set permissive = true to allow every origin`))
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			errs = append(errs, fmt.Errorf("cors: origin %q must look like https://example.com[:port]", origin))
+		}
+	}
+	for _, signal := range c.Policy.AllowedSignals {
+		if !slices.Contains(Signals, signal) {
+			errs = append(errs, fmt.Errorf("policy: unknown signal %q, expected one of %v", signal, Signals))
+		}
+	}
+	if c.Audit.MaxSize < 0 || c.Audit.MaxBackups < 0 {
+		errs = append(errs, errors.New("audit: max_size and max_backups cannot be negative"))
+	}
+	if c.Agent.HubURL != "" {
+		if u, err := url.Parse(c.Agent.HubURL); err != nil || !slices.Contains([]string{"http", "https", "ws", "wss"}, u.Scheme) || u.Host == "" {
+			errs = append(errs, fmt.Errorf("agent: hub_url %q must look like https://host:port", c.Agent.HubURL))
+		}
+		if c.Agent.Name == "" || c.Agent.Token == "" {
+			errs = append(errs, errors.New("agent: name and token are required to connect to a hub"))
+		}
+	}
+	if !auth.Role(c.Agent.Role).Valid() {
+		errs = append(errs, fmt.Errorf("agent: unknown role %q", c.Agent.Role))
+	}
+	for _, p := range []float64{c.Hub.Alerts.CPUPercent, c.Hub.Alerts.MemoryPercent, c.Hub.Alerts.StoragePercent} {
+		if p < 0 || p > 100 {
+			errs = append(errs, errors.New("hub: alert thresholds must be between 0 and 100"))
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListenerConfigs returns the configured listeners, or a TCP listener on ListenAddr if
+// there are none.
+func (c *Config) ListenerConfigs() []ListenerConfig {
+	if len(c.Listeners) == 0 {
+		return []ListenerConfig{{Network: "tcp", Address: c.ListenAddr}}
+	}
+	return c.Listeners
+}
+
+// FileMode returns the file mode of a unix socket.
+func (l ListenerConfig) FileMode() (os.FileMode, error) {
+	if l.Mode == "" {
+		return 0660, nil
+	}
+	mode, err := strconv.ParseUint(l.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q", l.Mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// Duration is a time.Duration written as a string like "1m30s" in the config file.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d Duration) D() time.Duration {
+	return time.Duration(d)
+}
+
+// Enabled reports whether the feature with the given name (the key in the config file) is enabled.
+func (f FeaturesConfig) Enabled(name string) bool {
+	switch name {
+	case "logs":
+		return f.Logs
+	case "signals":
+		return f.Signals
+	case "services":
+		return f.Services
+	case "power_actions":
+		return f.PowerActions
+	case "web_ui":
+		return f.WebUI
+	default:
+		return false
+	}
+}
+
+// RestartRequired returns the settings that differ between c and other but only take
+// effect when the server is restarted.
+func (c *Config) RestartRequired(other *Config) []string {
+	var settings []string
+	check := func(name string, changed bool) {
+		if changed {
+			settings = append(settings, name)
+		}
+	}
+	check("listen_addr", c.ListenAddr != other.ListenAddr)
+	check("listeners", !slices.Equal(c.Listeners, other.Listeners))
+	check("state_dir", c.StateDir != other.StateDir)
+	check("tls.cert_file", c.TLS.CertFile != other.TLS.CertFile)
+	check("tls.key_file", c.TLS.KeyFile != other.TLS.KeyFile)
+	check("tls.self_signed", c.TLS.SelfSigned != other.TLS.SelfSigned)
+	check("tls.hosts", !slices.Equal(c.TLS.Hosts, other.TLS.Hosts))
+	check("tls.client_ca_file", c.TLS.ClientCAFile != other.TLS.ClientCAFile)
+	check("tls.client_auth", c.TLS.ClientAuth != other.TLS.ClientAuth)
+	check("auth.users_file", c.Auth.UsersFile != other.Auth.UsersFile)
+	check("auth.tokens_file", c.Auth.TokensFile != other.Auth.TokensFile)
+	check("auth.sessions_file", c.Auth.SessionsFile != other.Auth.SessionsFile)
+	check("audit", c.Audit != other.Audit)
+	check("hub.enabled", c.Hub.Enabled != other.Hub.Enabled)
+	check("hub.agents_file", c.Hub.AgentsFile != other.Hub.AgentsFile)
+	// the role is read for every request from the hub
+	agent, otherAgent := c.Agent, other.Agent
+	agent.Role, otherAgent.Role = "", ""
+	check("agent", agent != otherAgent)
+	check("grpc.address", c.GRPC.Address != other.GRPC.Address)
+	return settings
+}
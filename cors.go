@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync/atomic"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/tiredkangaroo/system/config"
+)
+
+var corsHandler atomic.Pointer[fiber.Handler]
+
+// corsMiddleware applies the CORS policy of the current configuration.
+func corsMiddleware(c *fiber.Ctx) error {
+	return (*corsHandler.Load())(c)
+}
+
+func setCORSConfig(c config.CORSConfig) {
+	if c.Permissive {
+		slog.Warn("permissive CORS enabled, any website can use the api with a logged in user's cookie. only use this for development")
+	}
+	handler := cors.New(cors.Config{
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowMethods:     "GET, POST, PATCH, DELETE, OPTIONS",
+		AllowCredentials: true,
+		ExposeHeaders:    "Content-Length, Content-Type",
+		MaxAge:           int(c.MaxAge.D().Seconds()),
+		AllowOriginsFunc: func(origin string) bool {
+			return c.Permissive || slices.Contains(c.AllowOrigins, origin)
+		},
+	})
+	corsHandler.Store(&handler)
+}
+
+// csrfMiddleware rejects state-changing requests and websocket upgrades sent by a browser
+// from an origin that is not allowed. CORS alone does not stop these: simple POSTs are sent
+// without a preflight and websockets are not subject to CORS at all, and both carry the
+// session cookie. requests without an Origin or Referer header don't come from a browser
+// and are let through.
+func csrfMiddleware(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		if !websocket.IsWebSocketUpgrade(c) {
+			return c.Next()
+		}
+	}
+	origin := c.Get(fiber.HeaderOrigin)
+	if origin == "" {
+		referer, err := url.Parse(c.Get(fiber.HeaderReferer))
+		if err != nil || referer.Host == "" {
+			return c.Next()
+		}
+		origin = referer.Scheme + "://" + referer.Host
+	}
+	if !originAllowed(c, origin) {
+		securityLog.Warn("cross-origin request rejected", "origin", origin, "method", c.Method(), "path", c.Path(), "ip", c.IP())
+		return sendErrorMap(c, fiber.StatusForbidden, errors.New("requests from this origin are not allowed, add it to cors.allow_origins"))
+	}
+	return c.Next()
+}
+
+// originAllowed reports whether origin is the server's own origin or allowed by the
+// configuration.
+func originAllowed(c *fiber.Ctx, origin string) bool {
+	cors := conf().CORS
+	return cors.Permissive || origin == c.Protocol()+"://"+c.Hostname() || slices.Contains(cors.AllowOrigins, origin)
+}
@@ -0,0 +1,145 @@
+package fake
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/tiredkangaroo/system/system"
+)
+
+const (
+	gib = 1 << 30
+	gb  = 1000 * 1000 * 1000
+)
+
+// NewDemo returns a made-up server with a busy hour of metrics, a few dozen snapshots of
+// processes (and a backup that comes and goes), services and logs, looped forever. It is
+// deterministic except for the times of its logs, which are relative to now.
+func NewDemo() *FakeSystem {
+	f := &FakeSystem{
+		Static: system.StaticInfo{
+			OS:              "linux",
+			OSRelease:       "6.8.0-demo",
+			Hostname:        "demo",
+			CPU:             "Demo CPU @ 3.00GHz",
+			NumCPU:          8,
+			Arch:            "amd64",
+			Memory:          16 * gib,
+			StorageCapacity: 512 * gb,
+			HasBattery:      true,
+			Battery:         "DEMO-BAT",
+			PowerActions: []system.PowerAction{
+				system.PowerActionShutdown, system.PowerActionReboot, system.PowerActionSuspend,
+				system.PowerActionHibernate, system.PowerActionHybridSleep, system.PowerActionSuspendThenHibernate,
+			},
+		},
+		Services: []system.Service{
+			{Name: "api.service", Status: "running", Description: "Demo API server"},
+			{Name: "backup.service", Status: "failed", Description: "Nightly backup"},
+			{Name: "bluetooth.service", Status: "dead", Description: "Bluetooth service"},
+			{Name: "cron.service", Status: "running", Description: "Regular background program processing daemon"},
+			{Name: "nginx.service", Status: "running", Description: "A high performance web server and a reverse proxy server"},
+			{Name: "postgresql.service", Status: "running", Description: "PostgreSQL RDBMS"},
+			{Name: "ssh.service", Status: "running", Description: "OpenBSD Secure Shell server"},
+			{Name: "systemd-journald.service", Status: "running", Description: "Journal Service"},
+			{Name: "ufw.service", Status: "exited", Description: "Uncomplicated firewall"},
+		},
+		Loop: true,
+	}
+	for i := range 3600 { // an hour at the default metrics interval
+		f.Metrics = append(f.Metrics, demoMetrics(i))
+	}
+	for i := range 60 { // five minutes at the default processes interval
+		f.Processes = append(f.Processes, demoProcesses(i))
+	}
+	now := time.Now()
+	for i, e := range demoLogs {
+		e.Time = now.Add(-time.Duration(len(demoLogs)-i) * time.Minute)
+		f.Logs = append(f.Logs, e)
+	}
+	return f
+}
+
+// wave is a sine wave of period samples between -1 and 1.
+func wave(i, period int) float64 {
+	return math.Sin(2 * math.Pi * float64(i) / float64(period))
+}
+
+func demoMetrics(i int) system.Metrics {
+	cpu := 30 + 20*wave(i, 300) + 8*wave(i, 17)
+	return system.Metrics{
+		CPU_Usage:      cpu,
+		CPU_Temp:       40 + cpu*0.4,
+		MemoryUsed:     uint64(6*gib + gib*wave(i, 900)),
+		StorageUsed:    210*gb + uint64(i)*4096,
+		BatteryTemp:    30 + 2*wave(i, 1200),
+		BatteryPercent: 100 - float64(i)/3600*40,
+		BatteryStatus:  "discharging",
+		Uptime:         3*24*60*60 + uint64(i),
+	}
+}
+
+func demoProcesses(i int) []system.Process {
+	p := func(pid int32, name string, threads int32, cpu float64, mem float32, parent int32) system.Process {
+		return system.Process{
+			PID: pid, Name: name, Status: "sleep", Threads: threads, CPUPercent: cpu, MemoryPercent: mem,
+			ParentPID: parent, NumFDs: 12 + threads,
+			VoluntaryCtxSwitchRate: 2 * float64(threads), InvoluntaryCtxSwitchRate: cpu / 10,
+		}
+	}
+	processes := []system.Process{
+		p(1, "systemd", 1, 0.1, 0.1, 0),
+		p(412, "systemd-journald", 1, 0.2+0.1*wave(i, 7), 0.2, 1),
+		p(598, "sshd", 1, 0, 0.1, 1),
+		p(611, "cron", 1, 0, 0, 1),
+		p(640, "postgres", 6, 4+3*wave(i, 20), 8.5, 1),
+		p(702, "postgres", 1, 1.5+wave(i, 11), 1.2, 640),
+		p(703, "postgres", 1, 0.5, 1.1, 640),
+		p(720, "nginx", 1, 0.1, 0.3, 1),
+		p(721, "nginx", 1, 2+1.5*wave(i, 9), 0.6, 720),
+		p(722, "nginx", 1, 2+1.5*wave(i+3, 9), 0.6, 720),
+		p(850, "node", 11, 12+10*wave(i, 30), 6.3, 1),
+		p(1203, "bash", 1, 0, 0.1, 598),
+	}
+	processes[0].ChildrenPIDs = []int32{412, 598, 611, 640, 720, 850}
+	processes[4].ChildrenPIDs = []int32{702, 703}
+	processes[4].DiskWriteRate = 64*1024 + 48*1024*wave(i, 20)
+	processes[7].ChildrenPIDs = []int32{721, 722}
+	processes[10].Status = "running"
+	if i >= 20 && i < 35 {
+		backup := p(1250, "backup.sh", 2, 35, 1.5, 611)
+		backup.Status = "running"
+		backup.DiskReadRate, backup.DiskWriteRate = 80*1024*1024, 40*1024*1024
+		processes = append(processes, backup)
+		processes[3].ChildrenPIDs = []int32{1250}
+	}
+	return processes
+}
+
+// RunDemo logs the entries of the services of the demo again, one every few seconds, until
+// ctx is done.
+func (f *FakeSystem) RunDemo(ctx context.Context) {
+	entries := slices.DeleteFunc(slices.Clone(demoLogs), func(e LogEntry) bool { return e.Service == "" })
+	f.Replay(ctx, 5*time.Second, entries)
+}
+
+var demoLogs = []LogEntry{
+	{Service: "", Message: "Linux version 6.8.0-demo (demo@demo) #1 SMP PREEMPT_DYNAMIC", Boot: 1},
+	{Service: "", Message: "Linux version 6.8.0-demo (demo@demo) #1 SMP PREEMPT_DYNAMIC"},
+	{Service: "systemd-journald.service", Message: "Journal started"},
+	{Service: "ssh.service", Message: "Server listening on 0.0.0.0 port 22."},
+	{Service: "postgresql.service", Message: "database system is ready to accept connections"},
+	{Service: "nginx.service", Message: "Started A high performance web server and a reverse proxy server."},
+	{Service: "api.service", Message: "listening on :3000"},
+	{Service: "nginx.service", Message: `203.0.113.7 - - "GET /api/orders HTTP/1.1" 200 1532`},
+	{Service: "api.service", Message: "processed 12 jobs in 84ms"},
+	{Service: "cron.service", Message: "(root) CMD (run-parts /etc/cron.hourly)"},
+	{Service: "backup.service", Message: "backup.sh: upload failed: connection reset by peer"},
+	{Service: "backup.service", Message: "backup.service: Failed with result 'exit-code'."},
+	{Service: "postgresql.service", Message: "checkpoint complete: wrote 431 buffers (2.6%)"},
+	{Service: "nginx.service", Message: `198.51.100.23 - - "POST /api/login HTTP/1.1" 401 42`},
+	{Service: "ssh.service", Message: "Accepted publickey for demo from 192.0.2.10 port 52144"},
+	{Service: "api.service", Message: "processed 9 jobs in 61ms"},
+}
@@ -0,0 +1,315 @@
+// Package fake is a System that doesn't touch the machine it runs on. Everything it returns
+// is scripted, so the server can be tested (and demoed) deterministically.
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/tiredkangaroo/system/system"
+)
+
+var ErrServiceNotFound = errors.New("service not found")
+
+// FakeSystem is a scripted System. Set its fields before it's used, and change it with its
+// methods afterwards. The zero value has no metrics, processes, services or logs.
+type FakeSystem struct {
+	Static system.StaticInfo
+
+	// Metrics and Processes are returned in turn, one per call, and the last one is
+	// returned again once they have all been, or the first one if Loop is set.
+	Metrics   []system.Metrics
+	Processes [][]system.Process
+	Loop      bool
+
+	// Services are changed by StartService, StopService and RestartService.
+	Services []system.Service
+
+	// Logs are the system logs, and the service logs are the entries of the service.
+	Logs []LogEntry
+
+	// Errors makes methods fail, by method name (e.g. GetServices) or by method name and
+	// argument (e.g. "StartService nginx.service" or "SignalProcess 42").
+	Errors map[string]error
+
+	mu        sync.Mutex
+	metrics   int // calls of GetMetrics
+	processes int
+	stopped   map[int32]bool // by SIGSTOP, until SIGCONT
+	killed    map[int32]bool
+	calls     []string
+	followers map[*follower]struct{}
+}
+
+// next returns the value of a script after calls calls and counts the call.
+func next[T any](script []T, calls *int, loop bool) T {
+	var zero T
+	if len(script) == 0 {
+		return zero
+	}
+	i := index(len(script), *calls, loop)
+	*calls++
+	return script[i]
+}
+
+// index returns the index in a script of n values after calls calls.
+func index(n, calls int, loop bool) int {
+	if loop {
+		return calls % n
+	}
+	return min(calls, n-1)
+}
+
+// maxCalls is how many calls are remembered.
+const maxCalls = 1000
+
+// err returns the error set for the method, or for the method and arg, and records the call.
+func (f *FakeSystem) err(method string, arg ...any) error {
+	call := strings.TrimSpace(method + " " + fmt.Sprint(arg...))
+	if len(f.calls) == maxCalls {
+		f.calls = slices.Delete(f.calls, 0, maxCalls/2)
+	}
+	f.calls = append(f.calls, call)
+	if err := f.Errors[call]; err != nil {
+		return err
+	}
+	return f.Errors[method]
+}
+
+// Fail makes the method (or the method with an argument, e.g. "StartService nginx.service")
+// return err from now on, or succeed again if err is nil.
+func (f *FakeSystem) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Errors == nil {
+		f.Errors = make(map[string]error)
+	}
+	if err == nil {
+		delete(f.Errors, method)
+		return
+	}
+	f.Errors[method] = err
+}
+
+// Calls returns the last 1000 calls, e.g. "GetMetrics", "StartService nginx.service" or
+// "WallMessage going down".
+func (f *FakeSystem) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// Called reports how many times call (as returned by Calls) was made.
+func (f *FakeSystem) Called(call string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c == call {
+			n++
+		}
+	}
+	return n
+}
+
+// PushMetrics adds metrics to the script, returned after the ones already in it.
+func (f *FakeSystem) PushMetrics(m ...system.Metrics) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.Loop {
+		f.metrics = min(f.metrics, len(f.Metrics)) // the pushed ones come next
+	}
+	f.Metrics = append(f.Metrics, m...)
+}
+
+// PushProcesses adds lists of processes to the script, returned after the ones already in it.
+func (f *FakeSystem) PushProcesses(p ...[]system.Process) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.Loop {
+		f.processes = min(f.processes, len(f.Processes))
+	}
+	f.Processes = append(f.Processes, p...)
+}
+
+// SetService adds a service, or replaces the service with the same name.
+func (f *FakeSystem) SetService(svc system.Service) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := f.service(svc.Name); i != -1 {
+		f.Services[i] = svc
+		return
+	}
+	f.Services = append(f.Services, svc)
+}
+
+func (f *FakeSystem) GetStaticInfo() (system.StaticInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("GetStaticInfo"); err != nil {
+		return system.StaticInfo{}, err
+	}
+	info := f.Static
+	info.PowerActions = slices.Clone(info.PowerActions)
+	return info, nil
+}
+
+func (f *FakeSystem) GetMetrics(fields system.Fields) (system.Metrics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("GetMetrics"); err != nil {
+		return system.Metrics{}, err
+	}
+	m := next(f.Metrics, &f.metrics, f.Loop)
+	return selectMetrics(m, fields), nil
+}
+
+// selectMetrics returns the metrics in fields, the others are zero like those of a real
+// system that were not collected.
+func selectMetrics(m system.Metrics, fields system.Fields) system.Metrics {
+	var s system.Metrics
+	if fields.Has(system.FieldCPUUsage) {
+		s.CPU_Usage = m.CPU_Usage
+	}
+	if fields.Has(system.FieldCPUTemp) {
+		s.CPU_Temp = m.CPU_Temp
+	}
+	if fields.Has(system.FieldMemoryUsed) {
+		s.MemoryUsed = m.MemoryUsed
+	}
+	if fields.Has(system.FieldStorageUsed) {
+		s.StorageUsed = m.StorageUsed
+	}
+	if fields.Has(system.FieldBatteryTemp) {
+		s.BatteryTemp = m.BatteryTemp
+	}
+	if fields.Has(system.FieldBatteryPercent) {
+		s.BatteryPercent = m.BatteryPercent
+	}
+	if fields.Has(system.FieldBatteryStatus) {
+		s.BatteryStatus = m.BatteryStatus
+	}
+	if fields.Has(system.FieldUptime) {
+		s.Uptime = m.Uptime
+	}
+	return s
+}
+
+// GetProcesses returns the next processes of the script, without those that were killed.
+func (f *FakeSystem) GetProcesses() ([]system.Process, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("GetProcesses"); err != nil {
+		return nil, err
+	}
+	script := next(f.Processes, &f.processes, f.Loop)
+	processes := make([]system.Process, 0, len(script))
+	for _, p := range script {
+		if f.killed[p.PID] {
+			continue
+		}
+		if f.stopped[p.PID] {
+			p.Status = "stop"
+		}
+		p.ChildrenPIDs = slices.Clone(p.ChildrenPIDs)
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+func (f *FakeSystem) GetServices() ([]system.Service, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("GetServices"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.Services), nil
+}
+
+// SignalProcess stops (SIGSTOP), continues (SIGCONT) or kills (any other signal) one of the
+// processes last returned by GetProcesses, or of the first processes of the script.
+func (f *FakeSystem) SignalProcess(pid int32, signal syscall.Signal) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("SignalProcess", pid); err != nil {
+		return err
+	}
+	f.calls[len(f.calls)-1] += " " + signal.String()
+	exists := false
+	if len(f.Processes) > 0 {
+		last := f.Processes[index(len(f.Processes), max(f.processes-1, 0), f.Loop)]
+		exists = slices.ContainsFunc(last, func(p system.Process) bool { return p.PID == pid })
+	}
+	if !exists || f.killed[pid] {
+		return syscall.ESRCH
+	}
+	if f.stopped == nil {
+		f.stopped, f.killed = make(map[int32]bool), make(map[int32]bool)
+	}
+	switch signal {
+	case syscall.SIGSTOP:
+		f.stopped[pid] = true
+	case syscall.SIGCONT:
+		delete(f.stopped, pid)
+	default:
+		f.killed[pid] = true
+	}
+	return nil
+}
+
+// service returns the index of the service named name, with or without .service.
+func (f *FakeSystem) service(name string) int {
+	name = strings.TrimSuffix(name, ".service")
+	return slices.IndexFunc(f.Services, func(s system.Service) bool {
+		return strings.TrimSuffix(s.Name, ".service") == name
+	})
+}
+
+// setServiceStatus sets the status of a service, like systemctl would.
+func (f *FakeSystem) setServiceStatus(method, name, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err(method, name); err != nil {
+		return err
+	}
+	i := f.service(name)
+	if i == -1 {
+		return fmt.Errorf("%s: %w", name, ErrServiceNotFound)
+	}
+	f.Services[i].Status = status
+	return nil
+}
+
+func (f *FakeSystem) StartService(name string) error {
+	return f.setServiceStatus("StartService", name, "running")
+}
+
+func (f *FakeSystem) StopService(name string) error {
+	return f.setServiceStatus("StopService", name, "dead")
+}
+
+func (f *FakeSystem) RestartService(name string) error {
+	return f.setServiceStatus("RestartService", name, "running")
+}
+
+// call records a call that does nothing else, e.g. a power action.
+func (f *FakeSystem) call(method string, arg ...any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err(method, arg...)
+}
+
+func (f *FakeSystem) Shutdown() error             { return f.call("Shutdown") }
+func (f *FakeSystem) Reboot() error               { return f.call("Reboot") }
+func (f *FakeSystem) Suspend() error              { return f.call("Suspend") }
+func (f *FakeSystem) Hibernate() error            { return f.call("Hibernate") }
+func (f *FakeSystem) HybridSleep() error          { return f.call("HybridSleep") }
+func (f *FakeSystem) SuspendThenHibernate() error { return f.call("SuspendThenHibernate") }
+
+func (f *FakeSystem) WallMessage(message string) error {
+	return f.call("WallMessage", message)
+}
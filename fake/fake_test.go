@@ -0,0 +1,127 @@
+package fake
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tiredkangaroo/system/system"
+)
+
+func TestScript(t *testing.T) {
+	f := &FakeSystem{Metrics: []system.Metrics{{CPU_Usage: 1}, {CPU_Usage: 2}}}
+	var got []float64
+	for range 3 {
+		m, _ := f.GetMetrics(system.MetricsFields)
+		got = append(got, m.CPU_Usage)
+	}
+	f.PushMetrics(system.Metrics{CPU_Usage: 3})
+	m, _ := f.GetMetrics(system.MetricsFields)
+	got = append(got, m.CPU_Usage)
+	if want := []float64{1, 2, 2, 3}; !equal(got, want) {
+		t.Fatalf("cpu usage = %v, want %v", got, want)
+	}
+
+	f = &FakeSystem{Metrics: []system.Metrics{{CPU_Usage: 1}, {CPU_Usage: 2}}, Loop: true}
+	got = nil
+	for range 3 {
+		m, _ := f.GetMetrics(system.MetricsFields)
+		got = append(got, m.CPU_Usage)
+	}
+	if want := []float64{1, 2, 1}; !equal(got, want) {
+		t.Fatalf("looped cpu usage = %v, want %v", got, want)
+	}
+
+	if m, _ := f.GetMetrics(system.FieldUptime); m.CPU_Usage != 0 {
+		t.Fatalf("cpu usage = %v without being requested", m.CPU_Usage)
+	}
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFailures(t *testing.T) {
+	f := &FakeSystem{Services: []system.Service{{Name: "nginx.service"}, {Name: "ssh.service"}}}
+	errFailed := errors.New("job failed")
+	f.Fail("StartService nginx.service", errFailed)
+	if err := f.StartService("nginx.service"); err != errFailed {
+		t.Fatalf("StartService(nginx.service) = %v, want %v", err, errFailed)
+	}
+	if err := f.StartService("ssh"); err != nil {
+		t.Fatalf("StartService(ssh) = %v", err)
+	}
+	if err := f.StartService("mysql"); !errors.Is(err, ErrServiceNotFound) {
+		t.Fatalf("StartService(mysql) = %v, want %v", err, ErrServiceNotFound)
+	}
+	f.Fail("StartService nginx.service", nil)
+	if err := f.StartService("nginx.service"); err != nil {
+		t.Fatalf("StartService(nginx.service) = %v after the failure was cleared", err)
+	}
+	if n := f.Called("StartService nginx.service"); n != 2 {
+		t.Fatalf("nginx was started %d times, want 2", n)
+	}
+	services, _ := f.GetServices()
+	if services[0].Status != "running" || services[1].Status != "running" {
+		t.Fatalf("services = %+v", services)
+	}
+}
+
+func TestSignals(t *testing.T) {
+	f := &FakeSystem{Processes: [][]system.Process{{{PID: 1}, {PID: 2}}}}
+	if err := f.SignalProcess(3, syscall.SIGTERM); err != syscall.ESRCH {
+		t.Fatalf("SignalProcess(3) = %v, want %v", err, syscall.ESRCH)
+	}
+	f.SignalProcess(1, syscall.SIGSTOP)
+	f.SignalProcess(2, syscall.SIGKILL)
+	processes, _ := f.GetProcesses()
+	if len(processes) != 1 || processes[0].Status != "stop" {
+		t.Fatalf("processes = %+v, want 1 stopped", processes)
+	}
+	f.SignalProcess(1, syscall.SIGCONT)
+	if processes, _ := f.GetProcesses(); processes[0].Status != "" {
+		t.Fatalf("processes = %+v, want 1 continued", processes)
+	}
+	if err := f.SignalProcess(2, syscall.SIGKILL); err != syscall.ESRCH {
+		t.Fatalf("SignalProcess(2) = %v after it was killed, want %v", err, syscall.ESRCH)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	f := &FakeSystem{Static: system.StaticInfo{Hostname: "host"}}
+	r, err := f.GetServiceLog("nginx", system.LogOptions{Follow: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Log(LogEntry{Time: time.Unix(0, 0), Service: "ssh.service", Message: "skipped"})
+	f.Log(LogEntry{Time: time.Unix(0, 0), Service: "nginx.service", Message: "followed"})
+	buf := make([]byte, 128)
+	n, _ := r.Read(buf)
+	if want := "Thu 1970-01-01 00:00:00 UTC host nginx: followed\n"; string(buf[:n]) != want {
+		t.Fatalf("followed %q, want %q", buf[:n], want)
+	}
+	r.Close()
+	if _, err := r.Read(buf); err != io.ErrClosedPipe {
+		t.Fatalf("read after close = %v", err)
+	}
+	for f.Following() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	r, _ = f.GetSystemLogs(system.LogOptions{})
+	all, _ := io.ReadAll(r)
+	if strings.Count(string(all), "\n") != 2 {
+		t.Fatalf("logs = %q, want both entries", all)
+	}
+}
@@ -0,0 +1,180 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tiredkangaroo/system/system"
+)
+
+// LogEntry is an entry of the journal.
+type LogEntry struct {
+	Time    time.Time
+	Service string // e.g. nginx.service, empty for the kernel
+	Message string
+	Boot    int // entries of other boots than 0 are left out with ThisBootOnly
+}
+
+// format formats the entry like journalctl -o short-full.
+func (e LogEntry) format(hostname string) string {
+	ident := strings.TrimSuffix(e.Service, ".service")
+	if ident == "" {
+		ident = "kernel"
+	}
+	return fmt.Sprintf("%s %s %s: %s\n", e.Time.UTC().Format("Mon 2006-01-02 15:04:05 MST"), hostname, ident, e.Message)
+}
+
+func (e LogEntry) matches(service string, opts system.LogOptions) bool {
+	switch {
+	case service != "" && strings.TrimSuffix(e.Service, ".service") != strings.TrimSuffix(service, ".service"):
+		return false
+	case opts.Since != nil && e.Time.Before(*opts.Since):
+		return false
+	case opts.Until != nil && e.Time.After(*opts.Until):
+		return false
+	case opts.ThisBootOnly && e.Boot != 0:
+		return false
+	}
+	return true
+}
+
+// follower receives the entries logged while a log is followed.
+type follower struct {
+	service string
+	opts    system.LogOptions
+	entries chan LogEntry
+	done    chan struct{}
+}
+
+func (f *FakeSystem) GetSystemLogs(opts system.LogOptions) (io.ReadCloser, error) {
+	return f.logs("GetSystemLogs", "", opts)
+}
+
+func (f *FakeSystem) GetServiceLog(name string, opts system.LogOptions) (io.ReadCloser, error) {
+	return f.logs("GetServiceLog", name, opts)
+}
+
+// logs returns the entries of the service (or every entry if it's empty) that match opts. If
+// opts.Follow is set, the entries logged with Log are streamed until the reader is closed.
+func (f *FakeSystem) logs(method, service string, opts system.LogOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var err error
+	if service != "" {
+		err = f.err(method, service)
+	} else {
+		err = f.err(method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, e := range f.Logs {
+		if e.matches(service, opts) {
+			lines = append(lines, e.format(f.Static.Hostname))
+		}
+	}
+	if opts.Lines > 0 && len(lines) > opts.Lines {
+		lines = lines[len(lines)-opts.Lines:]
+	}
+	if !opts.Follow {
+		return io.NopCloser(strings.NewReader(strings.Join(lines, ""))), nil
+	}
+
+	fl := &follower{service: service, opts: opts, entries: make(chan LogEntry, 64), done: make(chan struct{})}
+	if f.followers == nil {
+		f.followers = make(map[*follower]struct{})
+	}
+	f.followers[fl] = struct{}{}
+	r, w := io.Pipe()
+	hostname := f.Static.Hostname
+	go func() {
+		defer func() {
+			f.mu.Lock()
+			delete(f.followers, fl)
+			f.mu.Unlock()
+		}()
+		if len(lines) > 0 {
+			if _, err := io.WriteString(w, strings.Join(lines, "")); err != nil {
+				return
+			}
+		}
+		for {
+			select {
+			case <-fl.done:
+				return
+			case e := <-fl.entries:
+				if _, err := io.WriteString(w, e.format(hostname)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return &followReader{PipeReader: r, done: fl.done}, nil
+}
+
+type followReader struct {
+	*io.PipeReader
+	once sync.Once
+	done chan struct{}
+}
+
+func (r *followReader) Close() error {
+	r.once.Do(func() { close(r.done) })
+	return r.PipeReader.Close()
+}
+
+// Following returns how many readers are following logs, e.g. to check that they are
+// closed when the client goes away.
+func (f *FakeSystem) Following() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.followers)
+}
+
+// maxLogs is how many entries are kept, the oldest are dropped first.
+const maxLogs = 10000
+
+// Log adds an entry to the logs and sends it to those following them.
+func (f *FakeSystem) Log(entry LogEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.Logs) >= maxLogs {
+		f.Logs = slices.Delete(f.Logs, 0, len(f.Logs)-maxLogs/2)
+	}
+	f.Logs = append(f.Logs, entry)
+	for fl := range f.followers {
+		if !entry.matches(fl.service, fl.opts) {
+			continue
+		}
+		select {
+		case fl.entries <- entry:
+		default: // the follower is too slow, like a journal that was rotated
+		}
+	}
+}
+
+// Replay logs entries in turn, one every interval and with the current time, starting over
+// after the last one, until ctx is done.
+func (f *FakeSystem) Replay(ctx context.Context, interval time.Duration, entries []LogEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for i := 0; ; i = (i + 1) % len(entries) {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			e := entries[i]
+			e.Time = now
+			f.Log(e)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/config"
+	"github.com/tiredkangaroo/system/hub"
+)
+
+// fleet is the hub of the agents registered with this server, nil unless hub mode is enabled.
+var fleet *hub.Hub
+
+func hubOptions(c config.HubConfig) hub.Options {
+	return hub.Options{
+		PollInterval: c.PollInterval.D(),
+		Timeout:      c.Timeout.D(),
+		Thresholds: hub.Thresholds{
+			CPUPercent:     c.Alerts.CPUPercent,
+			MemoryPercent:  c.Alerts.MemoryPercent,
+			StoragePercent: c.Alerts.StoragePercent,
+		},
+	}
+}
+
+func hubInit() error {
+	c := conf().Hub
+	if !c.Enabled {
+		return nil
+	}
+	if c.AgentsFile == "" {
+		c.AgentsFile = filepath.Join(stateDir(), "agents.json")
+	}
+	agents, err := hub.NewAgentStore(c.AgentsFile)
+	if err != nil {
+		return err
+	}
+	fleet = hub.New(agents, hubOptions(c))
+	go fleet.Run(shuttingDown)
+	slog.Info("hub mode enabled", "agents_file", c.AgentsFile, "agents", len(fleet.Agents()), "poll_interval", c.PollInterval.D())
+	return nil
+}
+
+// requireHub rejects fleet requests when hub mode is disabled.
+func requireHub(c *fiber.Ctx) error {
+	if fleet == nil {
+		return sendErrorMap(c, fiber.StatusNotFound, errors.New("hub mode is disabled, set hub.enabled = true (or SYSTEM_HUB=true) to enable"))
+	}
+	return c.Next()
+}
+
+// registerAgentConnectRoute registers the websocket push agents connect to. it must be
+// registered before requireAuthMiddleware, agents authenticate with their own token.
+func registerAgentConnectRoute(api fiber.Router) {
+	api.Get("/fleet/connect", requireHub, agentAuthMiddleware, websocket.New(func(c *websocket.Conn) {
+		name := c.Locals("agent").(string)
+		slog.Info("agent connected", "agent", name, "remote_addr", c.RemoteAddr().String())
+		err := fleet.Attach(shuttingDown, name, c.Conn)
+		slog.Info("agent disconnected", "agent", name, "error", err)
+	}))
+}
+
+func agentAuthMiddleware(c *fiber.Ctx) error {
+	if retryAfter, ok := authLimiter.Allow(c.IP()); !ok {
+		return sendLockedOut(c, retryAfter)
+	}
+	name := c.Query("agent")
+	token, _ := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+	if err := fleet.Authenticate(name, token); err != nil {
+		return authFailure(c, name, "agent_token", err)
+	}
+	authLimiter.Success(c.IP())
+	if !websocket.IsWebSocketUpgrade(c) {
+		return sendErrorMap(c, fiber.StatusUpgradeRequired, errors.New("agents connect with a websocket"))
+	}
+	c.Locals("agent", name)
+	return c.Next()
+}
+
+func registerFleetRoutes(api fiber.Router) {
+	fleetAPI := api.Group("/fleet", requireHub)
+	fleetAPI.Get("/hosts", requirePermission(auth.PermReadMetrics), func(c *fiber.Ctx) error {
+		return c.JSON(fleet.Hosts())
+	})
+	fleetAPI.Get("/hosts/:name", requirePermission(auth.PermReadMetrics), func(c *fiber.Ctx) error {
+		host, info, err := fleet.Host(c.Params("name"))
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		}
+		return c.JSON(fiber.Map{"host": host, "info": info})
+	})
+	fleetAPI.Get("/alerts", requirePermission(auth.PermReadMetrics), func(c *fiber.Ctx) error {
+		return c.JSON(fleet.Alerts())
+	})
+	fleetAPI.Get("/agents", requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		return c.JSON(fleet.Agents())
+	})
+	fleetAPI.Post("/agents", auditMiddleware, requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		var agent hub.Agent
+		if err := c.BodyParser(&agent); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		token, err := fleet.Register(c.Context(), agent)
+		if errors.Is(err, hub.ErrAgentExists) {
+			return sendErrorMap(c, fiber.StatusConflict, err)
+		} else if err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		host, _, _ := fleet.Host(agent.Name)
+		if token == "" {
+			return c.JSON(fiber.Map{"host": host})
+		}
+		return c.JSON(fiber.Map{
+			"host":  host,
+			"token": token, // the only time the token of a push agent is returned
+		})
+	})
+	fleetAPI.Delete("/agents/:name", auditMiddleware, requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		err := fleet.Unregister(c.Params("name"))
+		return sendErrorMap(c, fiber.StatusNotFound, err)
+	})
+
+	// the api of every agent is available under /fleet/hosts/:name, so the web interface
+	// can be pointed at ${hub}/api/v1/fleet/hosts/${name} to manage a single host.
+	fleetAPI.Get("/hosts/:name/api/v1/info/ws", requirePermission(auth.PermReadMetrics), websocket.New(fleetInfoWebsocket))
+	fleetAPI.Get("/hosts/:name/api/v1/ws", requirePermission(auth.PermReadMetrics), requireFleetHost, topicsPermissions(false), websocket.New(topicsWebsocket(func(c *websocket.Conn) topicSource {
+		return &agentSource{name: c.Params("name")}
+	})))
+	fleetAPI.All("/hosts/:name/api/v1/*", proxyPermissionMiddleware, proxyAuditMiddleware, proxyHandler)
+}
+
+func requireFleetHost(c *fiber.Ctx) error {
+	if _, _, err := fleet.Host(c.Params("name")); err != nil {
+		return sendErrorMap(c, fiber.StatusNotFound, err)
+	}
+	return c.Next()
+}
+
+// fleetInfoWebsocket sends the info of an agent every websocket interval, fetched over
+// http since agents are not expected to accept websockets from the hub.
+func fleetInfoWebsocket(c *websocket.Conn) {
+	defer c.Close()
+	name := c.Params("name")
+	ticker := time.NewTicker(conf().Refresh.WebsocketInterval.D())
+	defer ticker.Stop()
+	for {
+		resp, err := fleet.Do(shuttingDown, name, http.MethodGet, "/api/v1/info", nil, nil)
+		if err != nil {
+			c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "agent is unreachable"), time.Now().Add(time.Second))
+			return
+		}
+		var info json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&info)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			slog.Error("fleet websocket get agent info", "agent", name, "status", resp.StatusCode, "error", err)
+			c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "agent returned an error"), time.Now().Add(time.Second))
+			return
+		}
+		if err := c.WriteMessage(websocket.TextMessage, info); err != nil {
+			return
+		}
+		select {
+		case <-shuttingDown.Done():
+			c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"), time.Now().Add(time.Second))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// proxyPermission returns the permission needed on the hub to make a request to the api
+// of an agent. the agent still checks the request against the scopes of its token.
+func proxyPermission(method, path string) auth.Permission {
+	switch {
+	case method == fiber.MethodGet && (path == "system/logs" || strings.HasPrefix(path, "service/") && strings.HasSuffix(path, "/logs")):
+		return auth.PermReadLogs
+	case method == fiber.MethodGet && (path == "info" || path == "auth" || path == "is_privileged" || path == "system/power/scheduled" ||
+		strings.HasPrefix(path, "process/") || strings.HasPrefix(path, "service/")):
+		return auth.PermReadMetrics
+	case method == fiber.MethodPost && strings.HasPrefix(path, "process/"):
+		return auth.PermSignalProcesses
+	case method == fiber.MethodPatch && strings.HasPrefix(path, "service/"):
+		return auth.PermManageServices
+	case (method == fiber.MethodPost || method == fiber.MethodDelete) && strings.HasPrefix(path, "system/"):
+		return auth.PermPowerActions
+	default:
+		return auth.PermAdmin
+	}
+}
+
+func proxyPermissionMiddleware(c *fiber.Ctx) error {
+	return requirePermission(proxyPermission(c.Method(), c.Params("*")))(c)
+}
+
+// proxyAuditMiddleware records the requests that change something on an agent.
+func proxyAuditMiddleware(c *fiber.Ctx) error {
+	if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+		return c.Next()
+	}
+	return auditMiddleware(c)
+}
+
+// proxyHandler forwards a request to an agent with the agent's token. the cookies and
+// credentials of the client are not forwarded.
+func proxyHandler(c *fiber.Ctx) error {
+	path := "/api/v1/" + c.Params("*")
+	if query := c.Request().URI().QueryString(); len(query) > 0 {
+		path += "?" + string(query)
+	}
+	header := http.Header{}
+	for _, key := range []string{fiber.HeaderContentType, fiber.HeaderAccept} {
+		if v := c.Get(key); v != "" {
+			header.Set(key, v)
+		}
+	}
+	var body io.Reader
+	if len(c.Body()) > 0 {
+		body = bytes.NewReader(bytes.Clone(c.Body()))
+	}
+	resp, err := fleet.Do(shuttingDown, c.Params("name"), c.Method(), path, header, body)
+	if errors.Is(err, hub.ErrAgentNotFound) {
+		return sendErrorMap(c, fiber.StatusNotFound, err)
+	} else if err != nil {
+		return sendErrorMap(c, fiber.StatusBadGateway, fmt.Errorf("agent is unreachable: %w", err))
+	}
+	c.Status(resp.StatusCode)
+	for _, key := range []string{fiber.HeaderContentType, fiber.HeaderRetryAfter, fiber.HeaderCacheControl} {
+		if v := resp.Header.Get(key); v != "" {
+			c.Set(key, v)
+		}
+	}
+	// the body is streamed, so that following logs works through the hub
+	return c.SendStream(trackStream(resp.Body), int(resp.ContentLength))
+}
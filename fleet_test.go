@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/tiredkangaroo/system/audit"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/hub"
+	"github.com/tiredkangaroo/system/system"
+)
+
+// newFleetServers returns an agent and a hub with login enabled, whose admin secret is
+// returned. the servers share their configuration and auth stores, so the api tokens
+// created on the hub work on the agent too.
+func newFleetServers(t *testing.T) (agent, hubServer *testServer, adminSecret string) {
+	agent = newTestServer(t, nil)
+	hubServer, adminSecret = newAuthServer(t, nil)
+	agents, err := hub.NewAgentStore(filepath.Join(t.TempDir(), "agents.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fleet = hub.New(agents, hubOptions(conf().Hub))
+	t.Cleanup(func() { fleet = nil })
+	return agent, hubServer, adminSecret
+}
+
+func TestFleetDisabled(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.call("GET", "/api/v1/fleet/hosts", nil, 404, nil)
+	s.call("GET", "/api/v1/fleet/hosts/web/api/v1/info", nil, 404, nil)
+	s.call("GET", "/api/v1/fleet/connect?agent=web", nil, 404, nil)
+}
+
+func TestFleetPullAgent(t *testing.T) {
+	agent, s, adminSecret := newFleetServers(t)
+	admin, _ := s.login("admin", code(t, adminSecret, 0))
+	var token struct{ Token string }
+	s.call("POST", "/api/v1/tokens", map[string]any{"name": "hub"}, 200, &token, "Cookie", admin)
+	s.call("POST", "/api/v1/fleet/agents", map[string]any{"name": "web", "url": "http://" + agent.listen(), "token": token.Token}, 200, nil, "Cookie", admin)
+	s.call("POST", "/api/v1/fleet/agents", map[string]any{"name": "web", "url": "http://" + agent.listen()}, 409, nil, "Cookie", admin)
+	s.call("POST", "/api/v1/fleet/agents", map[string]any{"name": "Not A Name", "url": "http://" + agent.listen()}, 400, nil, "Cookie", admin)
+
+	var hosts []hub.Host
+	s.call("GET", "/api/v1/fleet/hosts", nil, 200, &hosts, "Cookie", admin)
+	if len(hosts) != 1 || hosts[0].Name != "web" || hosts[0].Status != hub.StatusUp || hosts[0].Summary == nil || hosts[0].Summary.Hostname != "test" {
+		t.Fatalf("hosts = %+v", hosts)
+	}
+	var host struct {
+		Host hub.Host
+		Info system.SystemInfo
+	}
+	s.call("GET", "/api/v1/fleet/hosts/web", nil, 200, &host, "Cookie", admin)
+	if host.Info.CPU_Usage != 12.5 || len(host.Info.Processes) != 2 {
+		t.Fatalf("host = %+v", host)
+	}
+	s.call("GET", "/api/v1/fleet/hosts/nas", nil, 404, nil, "Cookie", admin)
+	var agents []hub.Agent
+	s.call("GET", "/api/v1/fleet/agents", nil, 200, &agents, "Cookie", admin)
+	if len(agents) != 1 || agents[0].Token != "" {
+		t.Fatalf("agents = %+v, want web without its token", agents)
+	}
+
+	// the api of the agent, through the hub
+	var info system.SystemInfo
+	s.call("GET", "/api/v1/fleet/hosts/web/api/v1/info?fields=cpu_usage", nil, 200, &info, "Cookie", admin)
+	if info.CPU_Usage != 12.5 || info.Processes != nil {
+		t.Fatalf("proxied info = %+v", info)
+	}
+	if logs := s.text("/api/v1/fleet/hosts/web/api/v1/service/nginx.service/logs", "Cookie", admin); !strings.Contains(logs, "GET /") {
+		t.Fatalf("proxied logs = %q", logs)
+	}
+	s.call("POST", "/api/v1/fleet/hosts/web/api/v1/process/42/signal/SIGTERM", nil, 200, nil, "Cookie", admin)
+	if !slices.Contains(agent.sys.Calls(), "SignalProcess 42 terminated") {
+		t.Fatalf("agent calls = %v, want SIGTERM sent to 42", agent.sys.Calls())
+	}
+	s.call("GET", "/api/v1/fleet/hosts/nas/api/v1/info", nil, 404, nil, "Cookie", admin)
+	var entries []audit.Entry
+	s.call("GET", "/api/v1/audit?route=/api/v1/fleet/hosts/:name/api/v1/*", nil, 200, &entries, "Cookie", admin)
+	if len(entries) != 1 || entries[0].Target != "web" || entries[0].Status != 200 {
+		t.Fatalf("proxy audit = %+v, want the signal", entries)
+	}
+
+	conn := s.dial("/api/v1/fleet/hosts/web/api/v1/info/ws", "Cookie", admin)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&info); err != nil || info.Hostname != "test" {
+		t.Fatalf("fleet info websocket = %+v, %v", info, err)
+	}
+
+	// the websocket is closed once the agent is gone
+	s.call("DELETE", "/api/v1/fleet/agents/web", nil, 200, nil, "Cookie", admin)
+	for {
+		if err := conn.ReadJSON(&info); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseInternalServerErr) {
+				t.Fatalf("fleet info websocket closed with %v, want the agent unreachable", err)
+			}
+			break
+		}
+	}
+	s.call("DELETE", "/api/v1/fleet/agents/web", nil, 404, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/fleet/hosts/web", nil, 404, nil, "Cookie", admin)
+}
+
+func TestFleetProxyPermissions(t *testing.T) {
+	agent, s, adminSecret := newFleetServers(t)
+	admin, _ := s.login("admin", code(t, adminSecret, 0))
+	var token struct{ Token string }
+	s.call("POST", "/api/v1/tokens", map[string]any{"name": "hub"}, 200, &token, "Cookie", admin)
+	s.call("POST", "/api/v1/fleet/agents", map[string]any{"name": "web", "url": "http://" + agent.listen(), "token": token.Token}, 200, nil, "Cookie", admin)
+	viewerSecret := secret(t)
+	s.call("POST", "/api/v1/users", map[string]any{"name": "viewer", "role": auth.RoleViewer, "totp_secret": viewerSecret}, 200, nil, "Cookie", admin)
+	viewer, _ := s.login("viewer", code(t, viewerSecret, 0))
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/api/v1/fleet/hosts", 200},
+		{"GET", "/api/v1/fleet/alerts", 200},
+		{"GET", "/api/v1/fleet/agents", 403},
+		{"POST", "/api/v1/fleet/agents", 403},
+		{"DELETE", "/api/v1/fleet/agents/web", 403},
+		{"GET", "/api/v1/fleet/hosts/web/api/v1/info", 200},
+		{"GET", "/api/v1/fleet/hosts/web/api/v1/system/logs", 200},
+		{"POST", "/api/v1/fleet/hosts/web/api/v1/process/1/signal/TERM", 403},
+		{"PATCH", "/api/v1/fleet/hosts/web/api/v1/service/nginx.service/restart", 403},
+		{"POST", "/api/v1/fleet/hosts/web/api/v1/system/reboot", 403},
+		{"DELETE", "/api/v1/fleet/hosts/web/api/v1/system/power/scheduled/1", 403},
+		{"GET", "/api/v1/fleet/hosts/web/api/v1/users", 403},
+		{"POST", "/api/v1/fleet/hosts/web/api/v1/tokens", 403},
+	}
+	for _, tt := range tests {
+		if got := s.request(tt.method, tt.path, nil, "Cookie", viewer).StatusCode; got != tt.status {
+			t.Errorf("%s %s as a viewer = %d, want %d", tt.method, tt.path, got, tt.status)
+		}
+	}
+	if calls := agent.sys.Calls(); slices.ContainsFunc(calls, func(c string) bool {
+		return strings.HasPrefix(c, "SignalProcess") || strings.HasPrefix(c, "RestartService") || c == "Reboot"
+	}) {
+		t.Fatalf("agent calls = %v, want none of the denied actions", calls)
+	}
+}
+
+func TestFleetPushAgent(t *testing.T) {
+	agent, s, adminSecret := newFleetServers(t)
+	admin, _ := s.login("admin", code(t, adminSecret, 0))
+	var registered struct {
+		Host  hub.Host
+		Token string
+	}
+	s.call("POST", "/api/v1/fleet/agents", map[string]any{"name": "nas", "mode": "push"}, 200, &registered, "Cookie", admin)
+	if registered.Token == "" || registered.Host.Status != hub.StatusUnknown {
+		t.Fatalf("registered = %+v", registered)
+	}
+
+	// agents authenticate with their own token, not as a user
+	s.call("GET", "/api/v1/fleet/connect?agent=nas", nil, 401, nil)
+	s.call("GET", "/api/v1/fleet/connect?agent=nas", nil, 401, nil, "Authorization", "Bearer sysagent_invalid")
+	s.call("GET", "/api/v1/fleet/connect?agent=web", nil, 401, nil, "Authorization", "Bearer "+registered.Token)
+	s.call("GET", "/api/v1/fleet/connect?agent=nas", nil, 426, nil, "Authorization", "Bearer "+registered.Token)
+	if _, resp, err := s.tryDial("/api/v1/fleet/connect?agent=nas", "Cookie", admin); err == nil || resp == nil || resp.StatusCode != 401 {
+		t.Fatalf("connecting with a session = %v, want 401", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		hub.RunAgent(ctx, hub.AgentOptions{
+			HubURL:       "http://" + s.listen(),
+			Name:         "nas",
+			Token:        registered.Token,
+			PushInterval: 10 * time.Millisecond,
+			Info: func() (*system.SystemInfo, error) {
+				return agent.infoService.GetSystemInfo(ctx, system.AllFields)
+			},
+			Transport: appTransport{agent.app},
+		})
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	eventually(t, "the agent to push its info", func() bool {
+		var hosts []hub.Host
+		s.call("GET", "/api/v1/fleet/hosts", nil, 200, &hosts, "Cookie", admin)
+		return len(hosts) == 1 && hosts[0].Status == hub.StatusUp
+	})
+
+	// the requests of the hub are made as the hub user with agent.role (operator)
+	var me authResponse
+	s.call("GET", "/api/v1/fleet/hosts/nas/api/v1/auth", nil, 200, &me, "Cookie", admin)
+	if me.User != "hub" || me.Role != auth.RoleOperator {
+		t.Fatalf("agent auth = %+v, want the hub as an operator", me)
+	}
+	s.call("POST", "/api/v1/fleet/hosts/nas/api/v1/process/42/signal/SIGTERM", nil, 200, nil, "Cookie", admin)
+	if !slices.Contains(agent.sys.Calls(), "SignalProcess 42 terminated") {
+		t.Fatalf("agent calls = %v, want SIGTERM sent to 42", agent.sys.Calls())
+	}
+	s.call("POST", "/api/v1/fleet/hosts/nas/api/v1/system/reboot", nil, 403, nil, "Cookie", admin) // operators can't
+	if logs := s.text("/api/v1/fleet/hosts/nas/api/v1/system/logs", "Cookie", admin); !strings.Contains(logs, "booted") {
+		t.Fatalf("proxied logs = %q", logs)
+	}
+
+	conn := s.dial("/api/v1/fleet/hosts/nas/api/v1/ws", "Cookie", admin)
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "static"})
+	msg := waitMessage(t, conn, "the static info of the agent", is("static", "snapshot"))
+	if static := as[system.StaticInfo](t, msg.Data); static.Hostname != "test" {
+		t.Fatalf("static = %+v", static)
+	}
+
+	// unregistering the agent disconnects it
+	s.call("DELETE", "/api/v1/fleet/agents/nas", nil, 200, nil, "Cookie", admin)
+	s.call("GET", "/api/v1/fleet/hosts/nas/api/v1/info", nil, 404, nil, "Cookie", admin)
+}
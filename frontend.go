@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/web"
+)
+
+// frontendHandler serves the embedded web interface. files under assets/ have content
+// hashes in their names and are cached forever, everything else is revalidated. paths
+// that don't match a file get index.html so that the app can route them itself.
+func frontendHandler(c *fiber.Ctx) error {
+	if !conf().Features.WebUI || strings.HasPrefix(c.Path(), "/api/") {
+		return c.Next()
+	}
+	if !web.Built() {
+		return c.Status(fiber.StatusNotFound).SendString("the web interface was not built into this binary, run `npm run build:embed` in frontend/ before building it.\n")
+	}
+	name := strings.TrimPrefix(path.Clean("/"+c.Params("*")), "/")
+	data, err := fs.ReadFile(web.Dist, name)
+	if err != nil || name == "" {
+		if path.Ext(name) != "" && name != "index.html" {
+			// a missing asset, not a route of the app
+			return c.Next()
+		}
+		name = "index.html"
+		if data, err = fs.ReadFile(web.Dist, name); err != nil {
+			return err
+		}
+	}
+	if strings.HasPrefix(name, "assets/") {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	} else {
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+	}
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderXFrameOptions, "DENY")
+	c.Type(path.Ext(name))
+	return c.Send(data)
+}
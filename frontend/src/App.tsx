@@ -1,19 +1,29 @@
 import { useEffect, useRef, useState } from "react";
-import { type SystemInfo } from "../types";
+import { type Diff, type SystemInfo, type TopicMessage } from "../types";
 import { StaticInfoView } from "./StaticInfo";
 import { DynamicInfoView } from "./DynamicInfo";
 import { ProcessesView } from "./Processes";
 import { ServicesView } from "./Services";
 import { LogsDialog } from "./LogsDialog";
+import { PowerScheduleView } from "./PowerSchedule";
+import { FleetView } from "./Fleet";
 import {
   MdDeleteOutline,
+  MdLogout,
   MdOutlineDescription,
+  MdOutlineBedtime,
   MdOutlineRestartAlt,
 } from "react-icons/md";
 import { FaPowerOff } from "react-icons/fa6";
 
 function App() {
-  const [serverURL, setServerURL] = useState<string | null>(null);
+  // the embedded build is served by the server it talks to, unless a server (such as a
+  // host of a hub) is given in the hash
+  const [serverURL, setServerURL] = useState<string | null>(
+    import.meta.env.MODE === "embed" && window.location.hash.length <= 1
+      ? window.location.origin
+      : null
+  );
   const [currentInfo, setCurrentInfo] = useState<SystemInfo | undefined>(
     undefined
   );
@@ -73,6 +83,7 @@ function App() {
   return (
     <div className="w-full h-full p-2 flex flex-col gap-2">
       <LogsDialog logURL={logURL} setLogURL={setLogURL} />
+      <FleetView serverURL={serverURL!} />
       <SystemInfoDisplay
         serverURL={serverURL!}
         info={currentInfo}
@@ -134,20 +145,24 @@ async function connectToWebSocket(
     credentials: "include",
   });
   if (!res.ok) {
-    const v = prompt("Authentication required. Please provide the TOTP code.");
+    const v = prompt(
+      "Authentication required. Please provide the TOTP code (as user:code if you have a user account)."
+    );
     if (!v) {
       alert("No TOTP code provided. Connection aborted.");
       return;
     }
-    if (v.length !== 8 || isNaN(Number(v))) {
+    const [user, code] = v.includes(":") ? v.split(":", 2) : ["", v];
+    if (code.length !== 8 || isNaN(Number(code))) {
       alert("Invalid TOTP code. Connection aborted.");
       return;
     }
-    const res2 = await fetch(`${url}/api/v1/auth`, {
-      method: "GET",
+    const res2 = await fetch(`${url}/api/v1/auth/login`, {
+      method: "POST",
       headers: {
-        Authorization: v,
+        "Content-Type": "application/json",
       },
+      body: JSON.stringify({ user: user, code: code }),
       credentials: "include",
     });
     if (!res2.ok) {
@@ -156,14 +171,54 @@ async function connectToWebSocket(
       return;
     }
   }
-  const ws = new WebSocket(`${url}/api/v1/info/ws`);
+  const ws = new WebSocket(`${url}/api/v1/ws`);
+  // topics send a snapshot and then only what changed, which is merged into info
+  let info: Partial<SystemInfo> = { processes: [], services: [] };
   ws.onopen = () => {
     setWsReadyState(ws.readyState);
+    const subscribe = (topic: string, interval?: number) =>
+      ws.send(JSON.stringify({ type: "subscribe", topic, interval }));
+    subscribe("static");
+    subscribe("metrics");
+    subscribe("processes", 2);
+    subscribe("services", 5);
   };
   ws.onmessage = (event) => {
-    const data = JSON.parse(event.data);
-    console.log(data);
-    setCurrentInfo(data);
+    const msg = JSON.parse(event.data) as TopicMessage;
+    switch (msg.topic) {
+      case "static":
+      case "metrics":
+        if (msg.type === "snapshot" || msg.type === "diff") {
+          info = { ...info, ...msg.data };
+        }
+        break;
+      case "processes":
+        if (msg.type === "snapshot") {
+          info = { ...info, processes: msg.data ?? [] };
+        } else if (msg.type === "diff") {
+          info = {
+            ...info,
+            processes: applyDiff(info.processes ?? [], msg.data, (p) => p.pid),
+          };
+        }
+        break;
+      case "services":
+        if (msg.type === "snapshot") {
+          info = { ...info, services: msg.data ?? [] };
+        } else if (msg.type === "diff") {
+          info = {
+            ...info,
+            services: applyDiff(info.services ?? [], msg.data, (s) => s.name),
+          };
+        }
+        break;
+    }
+    if (msg.type === "error") {
+      console.error(`websocket ${msg.topic ?? ""} error:`, msg.error);
+    }
+    if (info.hostname !== undefined && info.cpu_usage !== undefined) {
+      setCurrentInfo(info as SystemInfo);
+    }
     setWsReadyState(ws.readyState);
   };
   ws.onerror = (error) => {
@@ -180,6 +235,22 @@ async function connectToWebSocket(
   };
 }
 
+// applyDiff applies the added, removed and changed items of a topic diff to items.
+function applyDiff<T, K>(
+  items: T[],
+  diff: Diff<T, K>,
+  key: (item: T) => K
+): T[] {
+  const removed = new Set<K>(diff.removed ?? []);
+  const changed = new Map<K, T>(
+    (diff.changed ?? []).map((item) => [key(item), item])
+  );
+  return items
+    .filter((item) => !removed.has(key(item)))
+    .map((item) => changed.get(key(item)) ?? item)
+    .concat(diff.added ?? []);
+}
+
 interface SystemInfoDisplayProps {
   info: SystemInfo | undefined;
   wsReadyState: number | null;
@@ -299,8 +370,35 @@ function SystemInfoDisplay(props: SystemInfoDisplayProps) {
                   >
                     <MdOutlineRestartAlt />
                   </button>
+                  {props.info.power_actions?.includes("suspend") && (
+                    <button
+                      className="px-2 py-2 rounded-sm bg-gray-300 hover:bg-gray-400 cursor-pointer w-fit"
+                      title={"suspend " + props.info.hostname}
+                      onClick={() => {
+                        fetch(`${props.serverURL}/api/v1/system/power/suspend`, {
+                          method: "POST",
+                        });
+                      }}
+                    >
+                      <MdOutlineBedtime />
+                    </button>
+                  )}
                 </>
               )}
+              {requiresAuth && (
+                <button
+                  className="px-2 py-2 rounded-sm bg-gray-300 hover:bg-gray-400 cursor-pointer w-fit"
+                  title="log out"
+                  onClick={() => {
+                    fetch(`${props.serverURL}/api/v1/auth/logout`, {
+                      method: "POST",
+                      credentials: "include",
+                    }).then(() => window.location.reload());
+                  }}
+                >
+                  <MdLogout />
+                </button>
+              )}
               <button
                 className="px-2 py-2 rounded-sm bg-gray-300 hover:bg-gray-400 cursor-pointer w-fit"
                 title={`view ${props.info.hostname} logs`}
@@ -314,6 +412,12 @@ function SystemInfoDisplay(props: SystemInfoDisplayProps) {
           </div>
           <StaticInfoView info={props.info} />
           <DynamicInfoView info={props.info} />
+          {hasPrivilege && (
+            <PowerScheduleView
+              serverURL={props.serverURL}
+              powerActions={props.info.power_actions ?? ["shutdown", "reboot"]}
+            />
+          )}
           <ProcessesView
             serverURL={props.serverURL}
             processes={props.info.processes}
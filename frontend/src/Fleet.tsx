@@ -0,0 +1,111 @@
+import { useEffect, useState } from "react";
+import type { FleetAlert, FleetHost } from "../types";
+
+// FleetView lists the agents of a hub. it renders nothing if the server isn't a hub.
+export function FleetView({ serverURL }: { serverURL: string }) {
+  const [hosts, setHosts] = useState<FleetHost[] | null>(null);
+  const [alerts, setAlerts] = useState<FleetAlert[]>([]);
+
+  useEffect(() => {
+    function refresh() {
+      fetch(`${serverURL}/api/v1/fleet/hosts`, { credentials: "include" })
+        .then(async (res) => {
+          if (!res.ok) {
+            setHosts(null);
+            return;
+          }
+          setHosts(await res.json());
+          const alertsRes = await fetch(`${serverURL}/api/v1/fleet/alerts`, {
+            credentials: "include",
+          });
+          if (alertsRes.ok) setAlerts(await alertsRes.json());
+        })
+        .catch((error) => {
+          console.error("error fetching fleet:", error);
+        });
+    }
+    refresh();
+    const interval = setInterval(refresh, 10000);
+    return () => clearInterval(interval);
+  }, [serverURL]);
+
+  if (hosts === null) return null;
+  return (
+    <div className="w-full bg-sky-100 flex flex-col gap-2 py-4 px-2 rounded-sm">
+      <h1 className="text-2xl font-bold">fleet ({hosts.length})</h1>
+      {alerts.length > 0 && (
+        <div className="flex flex-col gap-1">
+          {alerts.map((a, idx) => (
+            <div key={idx} className="bg-red-200 rounded-sm px-2 py-1 text-sm">
+              <b>{a.host}</b>: {a.message}
+            </div>
+          ))}
+        </div>
+      )}
+      {hosts.length === 0 ? (
+        <p>
+          no agents registered, add one with{" "}
+          <code>POST /api/v1/fleet/agents</code>
+        </p>
+      ) : (
+        <table className="text-sm text-left">
+          <thead>
+            <tr>
+              <th>host</th>
+              <th>status</th>
+              <th>cpu</th>
+              <th>memory</th>
+              <th>storage</th>
+              <th>failed services</th>
+              <th>last seen</th>
+            </tr>
+          </thead>
+          <tbody>
+            {hosts.map((h) => (
+              <tr key={h.name} title={h.error}>
+                <td>
+                  <a
+                    className="text-blue-500 underline"
+                    href={`#${encodeURIComponent(
+                      `${serverURL}/api/v1/fleet/hosts/${h.name}`
+                    )}`}
+                    target="_blank"
+                  >
+                    {h.name}
+                  </a>
+                  {h.summary && h.summary.hostname !== h.name && (
+                    <span className="text-gray-500"> ({h.summary.hostname})</span>
+                  )}
+                </td>
+                <td>
+                  <span
+                    className={`px-2 rounded-4xl text-white ${
+                      h.status === "up"
+                        ? "bg-green-400"
+                        : h.status === "down"
+                        ? "bg-red-400"
+                        : "bg-gray-400"
+                    }`}
+                  >
+                    {h.status}
+                  </span>
+                </td>
+                <td>{h.summary ? `${h.summary.cpu_usage.toFixed(1)}%` : "-"}</td>
+                <td>
+                  {h.summary ? `${h.summary.memory_percent.toFixed(1)}%` : "-"}
+                </td>
+                <td>
+                  {h.summary ? `${h.summary.storage_percent.toFixed(1)}%` : "-"}
+                </td>
+                <td>{h.summary ? h.summary.failed_services : "-"}</td>
+                <td>
+                  {h.last_seen ? new Date(h.last_seen).toLocaleString() : "never"}
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  );
+}
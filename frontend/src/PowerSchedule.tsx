@@ -0,0 +1,108 @@
+import { useEffect, useState } from "react";
+import type { ScheduledPowerAction } from "../types";
+import { MdCancel } from "react-icons/md";
+
+export function PowerScheduleView({
+  serverURL,
+  powerActions,
+}: {
+  serverURL: string;
+  powerActions: string[];
+}) {
+  const [pending, setPending] = useState<ScheduledPowerAction[]>([]);
+  const [action, setAction] = useState("reboot");
+  const [at, setAt] = useState<Date | null>(null);
+  const [message, setMessage] = useState("");
+
+  function refresh() {
+    fetch(`${serverURL}/api/v1/system/power/scheduled`, {
+      method: "GET",
+      credentials: "include",
+    })
+      .then((res) => res.json())
+      .then((data) => setPending(data))
+      .catch((error) => {
+        console.error("error fetching scheduled power actions:", error);
+      });
+  }
+  useEffect(refresh, [serverURL]);
+
+  return (
+    <div className="w-full bg-purple-100 flex flex-col gap-2 py-4 px-2 rounded-sm">
+      <h1 className="text-2xl font-bold">scheduled power actions</h1>
+      <div className="flex flex-row gap-4 items-center flex-wrap">
+        <select
+          className="rounded-md border p-1"
+          value={action}
+          onChange={(e) => setAction(e.target.value)}
+        >
+          {powerActions.map((a) => (
+            <option key={a} value={a}>
+              {a}
+            </option>
+          ))}
+        </select>
+        <input
+          className="w-fit rounded-md border p-1"
+          type="datetime-local"
+          onChange={(e) => setAt(new Date(e.target.value))}
+        />
+        <input
+          className="rounded-md border p-1 min-w-[30%]"
+          placeholder="message to logged-in users (optional)"
+          value={message}
+          onChange={(e) => setMessage(e.target.value)}
+        />
+        <button
+          className="px-2 py-1 rounded-sm bg-gray-300 hover:bg-gray-400 cursor-pointer w-fit"
+          onClick={() => {
+            if (!at) {
+              alert("Pick a time to schedule the action at.");
+              return;
+            }
+            fetch(`${serverURL}/api/v1/system/power/schedule`, {
+              method: "POST",
+              credentials: "include",
+              headers: { "Content-Type": "application/json" },
+              body: JSON.stringify({
+                action: action,
+                at: Math.floor(at.getTime() / 1000),
+                message: message,
+              }),
+            }).then(async (res) => {
+              if (!res.ok) {
+                const errorData = await res.json();
+                alert(`Failed to schedule ${action}: ${errorData.error}`);
+              }
+              refresh();
+            });
+          }}
+        >
+          schedule
+        </button>
+      </div>
+      {pending.length === 0 ? (
+        <p>no power actions scheduled</p>
+      ) : (
+        pending.map((p) => (
+          <div key={p.id} className="flex flex-row gap-2 items-center">
+            <b>{p.action}</b> at <code>{new Date(p.at).toLocaleString()}</code>
+            {p.message && <i>"{p.message}"</i>}
+            <button
+              className="cursor-pointer"
+              title={`cancel scheduled ${p.action}`}
+              onClick={() => {
+                fetch(`${serverURL}/api/v1/system/power/scheduled/${p.id}`, {
+                  method: "DELETE",
+                  credentials: "include",
+                }).then(refresh);
+              }}
+            >
+              <MdCancel />
+            </button>
+          </div>
+        ))
+      )}
+    </div>
+  );
+}
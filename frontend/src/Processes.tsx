@@ -133,6 +133,20 @@ function Process(props: {
           )}{" "}
           ({props.process.memory_percent.toFixed(2)}%)
         </li>
+        {props.process.disk_read_rate !== -1 ? (
+          <li>
+            <span className="font-semibold">disk i/o:</span>{" "}
+            {memoryString(Math.round(props.process.disk_read_rate))}/s read,{" "}
+            {memoryString(Math.round(props.process.disk_write_rate))}/s written
+          </li>
+        ) : null}
+        {props.process.voluntary_ctx_switch_rate !== -1 ? (
+          <li>
+            <span className="font-semibold">context switches:</span>{" "}
+            {props.process.voluntary_ctx_switch_rate.toFixed(1)}/s voluntary,{" "}
+            {props.process.involuntary_ctx_switch_rate.toFixed(1)}/s involuntary
+          </li>
+        ) : null}
         {props.process.num_fds !== -1 ? (
           <li>
             <span className="font-semibold">open file descriptors:</span>{" "}
@@ -9,6 +9,7 @@ export interface SystemInfo {
   storage_capacity: number; // used
   has_battery: boolean; // used
   battery: string; // used
+  power_actions: string[]; // used
   cpu_usage: number; // used
   cpu_temp: number;
   memory_used: number; // used
@@ -31,6 +32,10 @@ export interface Process {
   parent_pid: number;
   num_fds: number;
   children_pids: number[];
+  disk_read_rate: number; // bytes per second, -1 if unknown
+  disk_write_rate: number;
+  voluntary_ctx_switch_rate: number; // per second, -1 if unknown
+  involuntary_ctx_switch_rate: number;
 }
 
 export interface Service {
@@ -38,3 +43,51 @@ export interface Service {
   status: string;
   description: string;
 }
+
+// a message of the topics websocket (/api/v1/ws)
+export interface TopicMessage {
+  topic?: string;
+  type: "snapshot" | "diff" | "data" | "end" | "error";
+  data?: any; // eslint-disable-line @typescript-eslint/no-explicit-any
+  error?: string;
+}
+
+export interface Diff<T, K> {
+  added?: T[];
+  removed?: K[];
+  changed?: T[];
+}
+
+export interface ScheduledPowerAction {
+  id: string;
+  action: string;
+  at: string;
+  message?: string;
+  created_at: string;
+}
+
+export interface FleetHost {
+  name: string;
+  mode: "pull" | "push";
+  url?: string;
+  status: "up" | "down" | "unknown";
+  last_seen?: string;
+  error?: string;
+  summary?: {
+    hostname: string;
+    os: string;
+    cpu_usage: number;
+    memory_percent: number;
+    storage_percent: number;
+    uptime: number;
+    processes: number;
+    failed_services: number;
+    alerts: number;
+  };
+}
+
+export interface FleetAlert {
+  host: string;
+  kind: string;
+  message: string;
+}
@@ -3,7 +3,10 @@ import react from "@vitejs/plugin-react-swc";
 import tailwindcss from "@tailwindcss/vite";
 
 // https://vite.dev/config/
-export default defineConfig({
-  base: "/system/",
+export default defineConfig(({ mode }) => ({
+  // the "embed" build is served at / by the server itself (see web/web.go),
+  // the default one on github pages
+  base: mode === "embed" ? "/" : "/system/",
+  build: mode === "embed" ? { outDir: "../web/dist", emptyOutDir: true } : {},
   plugins: [react(), tailwindcss()],
-});
+}));
@@ -0,0 +1,627 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tiredkangaroo/system/audit"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/system"
+	"github.com/tiredkangaroo/system/systempb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcInit creates the gRPC server and its listener if grpc.address is set. it is served
+// with the TLS configuration of the REST api.
+func grpcInit(sys system.System, infoService *system.SystemInfoService, powerScheduler *system.PowerScheduler, tlsConfig *tls.Config) (*grpc.Server, net.Listener, error) {
+	addr := conf().GRPC.Address
+	if addr == "" {
+		return nil, nil, nil
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("grpc %s: %w", addr, err)
+	}
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(grpcUnaryInterceptor),
+		grpc.StreamInterceptor(grpcStreamInterceptor),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	srv := grpc.NewServer(opts...)
+	systempb.RegisterSystemServiceServer(srv, &grpcServer{sys: sys, infoService: infoService, powerScheduler: powerScheduler})
+	slog.Info("grpc listening on", "addr", ln.Addr().String(), "tls", tlsConfig != nil)
+	return srv, ln, nil
+}
+
+// stopGRPC waits up to timeout for the calls in flight to finish, then cancels them.
+// streams of metrics and logs end as soon as shutdown begins.
+func stopGRPC(srv *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Warn("in-flight grpc calls did not finish in time", "timeout", timeout.String())
+		srv.Stop()
+	}
+}
+
+// grpcMethod is what a gRPC method requires, like the middlewares of its REST route.
+type grpcMethod struct {
+	perm       auth.Permission
+	feature    string // see requireFeature
+	privileged bool   // see privilegeMiddleware
+	audit      bool
+	attempt    bool // see auditAttempt
+}
+
+var grpcMethods = map[string]grpcMethod{
+	systempb.SystemService_GetInfo_FullMethodName:                    {perm: auth.PermReadMetrics},
+	systempb.SystemService_WatchMetrics_FullMethodName:               {perm: auth.PermReadMetrics},
+	systempb.SystemService_GetProcess_FullMethodName:                 {perm: auth.PermReadMetrics},
+	systempb.SystemService_SignalProcess_FullMethodName:              {perm: auth.PermSignalProcesses, feature: "signals", privileged: true, audit: true},
+	systempb.SystemService_GetService_FullMethodName:                 {perm: auth.PermReadMetrics},
+	systempb.SystemService_ControlService_FullMethodName:             {perm: auth.PermManageServices, feature: "services", privileged: true, audit: true},
+	systempb.SystemService_GetSystemLogs_FullMethodName:              {perm: auth.PermReadLogs, feature: "logs"},
+	systempb.SystemService_GetServiceLogs_FullMethodName:             {perm: auth.PermReadLogs, feature: "logs"},
+	systempb.SystemService_RunPowerAction_FullMethodName:             {perm: auth.PermPowerActions, feature: "power_actions", privileged: true, audit: true, attempt: true},
+	systempb.SystemService_SchedulePowerAction_FullMethodName:        {perm: auth.PermPowerActions, feature: "power_actions", privileged: true, audit: true},
+	systempb.SystemService_ListScheduledPowerActions_FullMethodName:  {perm: auth.PermReadMetrics},
+	systempb.SystemService_CancelScheduledPowerAction_FullMethodName: {perm: auth.PermPowerActions, feature: "power_actions", privileged: true, audit: true},
+}
+
+// grpcPrincipal is the authenticated identity of a gRPC call.
+type grpcPrincipal struct {
+	name   string
+	role   auth.Role
+	scopes []auth.Permission // of the api token, nil for client certificates
+}
+
+func grpcUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	p, err := grpcAuthenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp any
+	err = grpcAudit(ctx, p, info.FullMethod, req, func() error {
+		if err := grpcAuthorize(p, info.FullMethod); err != nil {
+			return err
+		}
+		grpcAuditAttempt(ctx, p, info.FullMethod, req)
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// grpcStreamInterceptor checks streaming calls, which only read and are not audited.
+func grpcStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	p, err := grpcAuthenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	if err := grpcAuthorize(p, info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func grpcPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// grpcAuthenticate authenticates a call like requireAuthMiddleware, with a client
+// certificate or an api token in the authorization metadata. there are no sessions.
+func grpcAuthenticate(ctx context.Context) (grpcPrincipal, error) {
+	if !authEnabled() {
+		return grpcPrincipal{name: "anonymous", role: auth.RoleAdmin}, nil
+	}
+	ip := grpcPeerIP(ctx)
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if name, role, ok := certIdentity(&info.State, ip); ok {
+				return grpcPrincipal{name: name, role: role}, nil
+			}
+		}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		bearer, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok {
+			continue
+		}
+		if retryAfter, ok := authLimiter.Allow(ip); !ok {
+			securityLog.Warn("rejected authentication attempt during lockout", "event", "locked_out", "ip", ip)
+			return grpcPrincipal{}, status.Errorf(codes.ResourceExhausted, "too many failed authentication attempts, try again in %s", retryAfter.Round(time.Second))
+		}
+		token, err := tokens.Authenticate(bearer)
+		if err != nil {
+			recordAuthFailure(ip, "", "api_token", err)
+			return grpcPrincipal{}, status.Error(codes.Unauthenticated, err.Error())
+		}
+		user, err := lookupUser(token.User)
+		if err != nil {
+			err = errors.New("owner of api token no longer exists")
+			recordAuthFailure(ip, token.User, "api_token", err)
+			return grpcPrincipal{}, status.Error(codes.Unauthenticated, err.Error())
+		}
+		authLimiter.Success(ip)
+		scopes := token.Scopes
+		if scopes == nil {
+			scopes = []auth.Permission{} // a token without scopes
+		}
+		return grpcPrincipal{name: user.Name, role: user.Role, scopes: scopes}, nil
+	}
+	return grpcPrincipal{}, status.Error(codes.Unauthenticated, `authentication required, provide an api token in the authorization metadata ("Bearer sys_...") or a client certificate`)
+}
+
+// grpcAuthorize checks the permission, feature and privileges a method requires.
+func grpcAuthorize(p grpcPrincipal, method string) error {
+	m, ok := grpcMethods[method]
+	if !ok {
+		m.perm = auth.PermAdmin
+	}
+	if !allowed(p.role, p.scopes, m.perm) {
+		return status.Errorf(codes.PermissionDenied, "this action requires the %s permission", m.perm)
+	}
+	if m.feature != "" && !conf().Features.Enabled(m.feature) {
+		return status.Errorf(codes.PermissionDenied, "%s is disabled by the server configuration", m.feature)
+	}
+	if m.privileged && conf().Policy.RequireRoot && !privileged() {
+		return status.Error(codes.PermissionDenied, "this action requires root privileges to perform (try running with sudo or as root)")
+	}
+	return nil
+}
+
+// grpcAudit records the outcome of call like auditMiddleware, for methods that change
+// something. the route of the entry is the full method name.
+func grpcAudit(ctx context.Context, p grpcPrincipal, method string, req any, call func() error) error {
+	if auditLogger == nil || !grpcMethods[method].audit {
+		return call()
+	}
+	err := call()
+
+	entry := grpcAuditEntry(ctx, p, method, req)
+	entry.Status = grpcHTTPStatus(status.Code(err))
+	if err != nil {
+		entry.Error = status.Convert(err).Message()
+	}
+	logAudit(entry)
+	return err
+}
+
+// grpcAuditAttempt records that a method which may never return is about to run, like
+// auditAttempt.
+func grpcAuditAttempt(ctx context.Context, p grpcPrincipal, method string, req any) {
+	if auditLogger == nil || !grpcMethods[method].attempt {
+		return
+	}
+	entry := grpcAuditEntry(ctx, p, method, req)
+	entry.Attempt = true
+	logAudit(entry)
+}
+
+// grpcAuditEntry returns the entry of a call, without its result.
+func grpcAuditEntry(ctx context.Context, p grpcPrincipal, method string, req any) audit.Entry {
+	var params map[string]string
+	if msg, ok := req.(proto.Message); ok {
+		params = grpcAuditParams(msg)
+	}
+	entry := audit.Entry{
+		Time:     time.Now(),
+		ClientIP: grpcPeerIP(ctx),
+		Identity: p.name,
+		Method:   "GRPC",
+		Route:    method,
+		Params:   params,
+	}
+	for _, key := range []string{"pid", "name", "id", "action"} {
+		if v, ok := params[key]; ok {
+			entry.Target = v
+			break
+		}
+	}
+	return entry
+}
+
+// grpcAuditParams collects the fields of a request like auditParams.
+func grpcAuditParams(msg proto.Message) map[string]string {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if json.Unmarshal(data, &fields) != nil || len(fields) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(fields))
+	for k, v := range fields {
+		data, _ := json.Marshal(v)
+		params[k] = strings.Trim(string(data), `"`)
+	}
+	return params
+}
+
+// grpcHTTPStatus maps a gRPC code to the http status recorded in the audit log.
+func grpcHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// grpcServer implements the gRPC api with the same operations as the REST routes.
+type grpcServer struct {
+	systempb.UnimplementedSystemServiceServer
+
+	sys            system.System
+	infoService    *system.SystemInfoService
+	powerScheduler *system.PowerScheduler
+}
+
+func (s *grpcServer) GetInfo(ctx context.Context, req *systempb.GetInfoRequest) (*systempb.SystemInfo, error) {
+	info, err := s.infoService.GetSystemInfo(ctx, system.AllFields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &systempb.SystemInfo{Static: staticInfoToProto(info.StaticInfo), Dynamic: dynamicInfoToProto(info.DynamicInfo)}, nil
+}
+
+func (s *grpcServer) WatchMetrics(req *systempb.WatchMetricsRequest, stream grpc.ServerStreamingServer[systempb.DynamicInfo]) error {
+	interval := conf().Refresh.WebsocketInterval.D()
+	if req.IntervalSeconds > 0 {
+		interval = time.Duration(req.IntervalSeconds) * time.Second
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	stop := context.AfterFunc(shuttingDown, cancel)
+	defer stop()
+	fields := system.AllFields
+	if req.ExcludeProcesses {
+		fields &^= system.FieldProcesses
+	}
+	if req.ExcludeServices {
+		fields &^= system.FieldServices
+	}
+	defer s.infoService.Watch(fields)()
+	for {
+		_, next, err := s.infoService.GetMetrics(ctx, system.MetricsFields)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		info, err := s.infoService.GetSystemInfo(ctx, fields)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := stream.Send(dynamicInfoToProto(info.DynamicInfo)); err != nil {
+			return err
+		}
+		// metrics are sent when they are collected, at most every interval
+		if !waitForUpdate(ctx, next, interval) {
+			break
+		}
+	}
+	if shuttingDown.Err() != nil {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	return stream.Context().Err()
+}
+
+func (s *grpcServer) GetProcess(ctx context.Context, req *systempb.GetProcessRequest) (*systempb.Process, error) {
+	processes, _, err := s.infoService.GetProcesses(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	i := slices.IndexFunc(processes, func(p system.Process) bool { return p.PID == req.Pid })
+	if i == -1 {
+		return nil, status.Error(codes.NotFound, "process not found")
+	}
+	return processToProto(processes[i]), nil
+}
+
+func (s *grpcServer) SignalProcess(ctx context.Context, req *systempb.SignalProcessRequest) (*systempb.SignalProcessResponse, error) {
+	name := strings.TrimPrefix(req.Signal.String(), "SIGNAL_")
+	signal, ok := signals[name]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "signal is invalid")
+	}
+	if !slices.Contains(conf().Policy.AllowedSignals, name) {
+		return nil, status.Errorf(codes.PermissionDenied, "sending %s is not allowed by the server configuration", name)
+	}
+	if err := s.sys.SignalProcess(req.Pid, signal); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &systempb.SignalProcessResponse{}, nil
+}
+
+func (s *grpcServer) GetService(ctx context.Context, req *systempb.GetServiceRequest) (*systempb.Service, error) {
+	services, _, err := s.infoService.GetServices(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	i := slices.IndexFunc(services, func(svc system.Service) bool { return svc.Name == req.Name })
+	if i == -1 {
+		return nil, status.Error(codes.NotFound, "service not found")
+	}
+	return serviceToProto(services[i]), nil
+}
+
+func (s *grpcServer) ControlService(ctx context.Context, req *systempb.ControlServiceRequest) (*systempb.ControlServiceResponse, error) {
+	if req.Action != systempb.ServiceAction_SERVICE_ACTION_START && isProtectedService(req.Name) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s is protected by the server configuration", req.Name)
+	}
+	var err error
+	switch req.Action {
+	case systempb.ServiceAction_SERVICE_ACTION_START:
+		err = s.sys.StartService(req.Name)
+	case systempb.ServiceAction_SERVICE_ACTION_STOP:
+		err = s.sys.StopService(req.Name)
+	case systempb.ServiceAction_SERVICE_ACTION_RESTART:
+		err = s.sys.RestartService(req.Name)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "action must be start, stop or restart")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &systempb.ControlServiceResponse{}, nil
+}
+
+func (s *grpcServer) GetSystemLogs(req *systempb.GetSystemLogsRequest, stream grpc.ServerStreamingServer[systempb.LogChunk]) error {
+	reader, err := s.sys.GetSystemLogs(logOptionsFromProto(req.Options))
+	return sendLogChunks(stream, reader, err)
+}
+
+func (s *grpcServer) GetServiceLogs(req *systempb.GetServiceLogsRequest, stream grpc.ServerStreamingServer[systempb.LogChunk]) error {
+	reader, err := s.sys.GetServiceLog(req.Name, logOptionsFromProto(req.Options))
+	return sendLogChunks(stream, reader, err)
+}
+
+// sendLogChunks streams logs like sendReader, until the end of the logs, the call is
+// cancelled or the server shuts down.
+func sendLogChunks(stream grpc.ServerStreamingServer[systempb.LogChunk], reader io.ReadCloser, err error) error {
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	r := trackStream(reader)
+	defer r.Close()
+	stop := context.AfterFunc(stream.Context(), func() { r.Close() })
+	defer stop()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&systempb.LogChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if stream.Context().Err() != nil {
+				return stream.Context().Err()
+			}
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+func (s *grpcServer) RunPowerAction(ctx context.Context, req *systempb.RunPowerActionRequest) (*systempb.RunPowerActionResponse, error) {
+	action, ok := powerActions[req.Action]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, system.ErrInvalidPowerAction.Error())
+	}
+	if err := s.checkPowerAction(ctx, action); err != nil {
+		return nil, err
+	}
+	if err := action.Run(s.sys); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &systempb.RunPowerActionResponse{}, nil
+}
+
+func (s *grpcServer) SchedulePowerAction(ctx context.Context, req *systempb.SchedulePowerActionRequest) (*systempb.ScheduledPowerAction, error) {
+	action, ok := powerActions[req.Action]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, system.ErrInvalidPowerAction.Error())
+	}
+	if err := s.checkPowerAction(ctx, action); err != nil {
+		return nil, err
+	}
+	if req.At == nil {
+		return nil, status.Error(codes.InvalidArgument, "at must be provided")
+	}
+	scheduled, err := s.powerScheduler.Schedule(action, req.At.AsTime(), req.Message)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return scheduledPowerActionToProto(scheduled), nil
+}
+
+// checkPowerAction returns an InvalidArgument error if the system doesn't support action.
+func (s *grpcServer) checkPowerAction(ctx context.Context, action system.PowerAction) error {
+	info, err := s.infoService.GetStaticInfo(ctx)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err := action.CheckSupported(info.PowerActions); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+func (s *grpcServer) ListScheduledPowerActions(ctx context.Context, req *systempb.ListScheduledPowerActionsRequest) (*systempb.ListScheduledPowerActionsResponse, error) {
+	resp := &systempb.ListScheduledPowerActionsResponse{}
+	for _, scheduled := range s.powerScheduler.Pending() {
+		resp.Actions = append(resp.Actions, scheduledPowerActionToProto(scheduled))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) CancelScheduledPowerAction(ctx context.Context, req *systempb.CancelScheduledPowerActionRequest) (*systempb.CancelScheduledPowerActionResponse, error) {
+	if err := s.powerScheduler.Cancel(req.Id); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &systempb.CancelScheduledPowerActionResponse{}, nil
+}
+
+var powerActions = map[systempb.PowerAction]system.PowerAction{
+	systempb.PowerAction_POWER_ACTION_SHUTDOWN:               system.PowerActionShutdown,
+	systempb.PowerAction_POWER_ACTION_REBOOT:                 system.PowerActionReboot,
+	systempb.PowerAction_POWER_ACTION_SUSPEND:                system.PowerActionSuspend,
+	systempb.PowerAction_POWER_ACTION_HIBERNATE:              system.PowerActionHibernate,
+	systempb.PowerAction_POWER_ACTION_HYBRID_SLEEP:           system.PowerActionHybridSleep,
+	systempb.PowerAction_POWER_ACTION_SUSPEND_THEN_HIBERNATE: system.PowerActionSuspendThenHibernate,
+}
+
+func powerActionToProto(action system.PowerAction) systempb.PowerAction {
+	for pb, a := range powerActions {
+		if a == action {
+			return pb
+		}
+	}
+	return systempb.PowerAction_POWER_ACTION_UNSPECIFIED
+}
+
+func staticInfoToProto(info system.StaticInfo) *systempb.StaticInfo {
+	pb := &systempb.StaticInfo{
+		Os:              info.OS,
+		OsRelease:       info.OSRelease,
+		Hostname:        info.Hostname,
+		Cpu:             info.CPU,
+		NumCpu:          int32(info.NumCPU),
+		Arch:            info.Arch,
+		Memory:          info.Memory,
+		StorageCapacity: info.StorageCapacity,
+		HasBattery:      info.HasBattery,
+		Battery:         info.Battery,
+	}
+	for _, action := range info.PowerActions {
+		pb.PowerActions = append(pb.PowerActions, powerActionToProto(action))
+	}
+	return pb
+}
+
+func dynamicInfoToProto(info system.DynamicInfo) *systempb.DynamicInfo {
+	pb := &systempb.DynamicInfo{
+		CpuUsage:       info.CPU_Usage,
+		CpuTemp:        info.CPU_Temp,
+		MemoryUsed:     info.MemoryUsed,
+		StorageUsed:    info.StorageUsed,
+		BatteryTemp:    info.BatteryTemp,
+		BatteryPercent: info.BatteryPercent,
+		BatteryStatus:  info.BatteryStatus,
+		Uptime:         info.Uptime,
+	}
+	for _, p := range info.Processes {
+		pb.Processes = append(pb.Processes, processToProto(p))
+	}
+	for _, svc := range info.Services {
+		pb.Services = append(pb.Services, serviceToProto(svc))
+	}
+	return pb
+}
+
+func processToProto(p system.Process) *systempb.Process {
+	return &systempb.Process{
+		Pid:           p.PID,
+		Name:          p.Name,
+		Status:        p.Status,
+		Threads:       p.Threads,
+		CpuPercent:    p.CPUPercent,
+		MemoryPercent: p.MemoryPercent,
+		ParentPid:     p.ParentPID,
+		NumFds:        p.NumFDs,
+		ChildrenPids:  p.ChildrenPIDs,
+
+		DiskReadRate:             p.DiskReadRate,
+		DiskWriteRate:            p.DiskWriteRate,
+		VoluntaryCtxSwitchRate:   p.VoluntaryCtxSwitchRate,
+		InvoluntaryCtxSwitchRate: p.InvoluntaryCtxSwitchRate,
+	}
+}
+
+func serviceToProto(svc system.Service) *systempb.Service {
+	return &systempb.Service{Name: svc.Name, Status: svc.Status, Description: svc.Description}
+}
+
+func scheduledPowerActionToProto(a system.ScheduledPowerAction) *systempb.ScheduledPowerAction {
+	return &systempb.ScheduledPowerAction{
+		Id:        a.ID,
+		Action:    powerActionToProto(a.Action),
+		At:        timestamppb.New(a.At),
+		Message:   a.Message,
+		CreatedAt: timestamppb.New(a.CreatedAt),
+	}
+}
+
+func logOptionsFromProto(pb *systempb.LogOptions) system.LogOptions {
+	var opts system.LogOptions
+	if pb == nil {
+		return opts
+	}
+	if pb.Since != nil {
+		t := pb.Since.AsTime()
+		opts.Since = &t
+	}
+	if pb.Until != nil {
+		t := pb.Until.AsTime()
+		opts.Until = &t
+	}
+	opts.ThisBootOnly = pb.ThisBootOnly
+	opts.Lines = int(pb.Lines)
+	opts.Follow = pb.Follow
+	return opts
+}
@@ -0,0 +1,241 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/tiredkangaroo/system/system"
+)
+
+// AgentOptions configure a push agent, which connects to a hub instead of being polled.
+type AgentOptions struct {
+	HubURL       string // e.g. https://hub:8080
+	Name         string // the name the agent is registered with
+	Token        string // returned when the agent was registered
+	CAFile       string // CA to verify the hub's certificate with
+	Insecure     bool
+	PushInterval time.Duration
+
+	Info      func() (*system.SystemInfo, error)
+	Transport http.RoundTripper // serves the requests of the hub
+}
+
+// ConnectURL returns the websocket url a push agent connects to.
+func ConnectURL(hubURL, name string) (string, error) {
+	u, err := url.Parse(strings.TrimRight(hubURL, "/"))
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("hub url %q must look like https://host:port", hubURL)
+	}
+	u.Path += "/api/v1/fleet/connect"
+	u.RawQuery = url.Values{"agent": {name}}.Encode()
+	return u.String(), nil
+}
+
+// RunAgent keeps the agent connected to the hub until ctx is done, reconnecting with a
+// backoff when the connection fails.
+func RunAgent(ctx context.Context, opts AgentOptions) {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := runAgent(ctx, opts)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		slog.Warn("disconnected from hub, reconnecting", "hub", opts.HubURL, "in", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func runAgent(ctx context.Context, opts AgentOptions) error {
+	connectURL, err := ConnectURL(opts.HubURL, opts.Name)
+	if err != nil {
+		return err
+	}
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: opts.Insecure},
+	}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return err
+		}
+		dialer.TLSClientConfig.RootCAs = x509.NewCertPool()
+		if !dialer.TLSClientConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in %s", opts.CAFile)
+		}
+	}
+	conn, resp, err := dialer.DialContext(ctx, connectURL, http.Header{"Authorization": {"Bearer " + opts.Token}})
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("%w: %s %s", err, resp.Status, bytes.TrimSpace(body))
+		}
+		return err
+	}
+	slog.Info("connected to hub", "hub", opts.HubURL, "name", opts.Name)
+
+	a := &agentConn{opts: opts, tunnel: newTunnel(conn), cancels: make(map[uint64]context.CancelFunc)}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "agent is shutting down"), time.Now().Add(time.Second))
+		conn.Close()
+	})
+	defer stop()
+	go a.push(ctx)
+	return a.serve(ctx)
+}
+
+// agentConn is the agent's end of the websocket. tunnel is only used to write.
+type agentConn struct {
+	opts   AgentOptions
+	tunnel *tunnel
+
+	mu      sync.Mutex
+	cancels map[uint64]context.CancelFunc
+}
+
+func (a *agentConn) push(ctx context.Context) {
+	ticker := time.NewTicker(a.opts.PushInterval)
+	defer ticker.Stop()
+	for {
+		info, err := a.opts.Info()
+		if err != nil {
+			slog.Error("get system info for hub", "error", err)
+		} else if err := a.tunnel.write(message{Type: "info", Info: info}); err != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *agentConn) serve(ctx context.Context) error {
+	conn := a.tunnel.conn
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeTimeout))
+	})
+	defer func() {
+		a.mu.Lock()
+		for _, cancel := range a.cancels {
+			cancel()
+		}
+		a.mu.Unlock()
+	}()
+	for {
+		var m message
+		if err := conn.ReadJSON(&m); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		switch m.Type {
+		case "request":
+			reqCtx, cancel := context.WithCancel(ctx)
+			a.mu.Lock()
+			a.cancels[m.ID] = cancel
+			a.mu.Unlock()
+			go a.handle(reqCtx, m)
+		case "cancel":
+			a.mu.Lock()
+			if cancel, ok := a.cancels[m.ID]; ok {
+				cancel()
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// handle serves a request of the hub and sends the response back in chunks.
+func (a *agentConn) handle(ctx context.Context, m message) {
+	defer func() {
+		a.mu.Lock()
+		a.cancels[m.ID]()
+		delete(a.cancels, m.ID)
+		a.mu.Unlock()
+	}()
+	end := func(err error) {
+		msg := message{Type: "end", ID: m.ID}
+		if err != nil {
+			msg.Error = err.Error()
+		}
+		a.tunnel.write(msg)
+	}
+	req, err := http.NewRequestWithContext(ctx, m.Method, "http://"+a.opts.Name+m.Path, bytes.NewReader(m.Data))
+	if err != nil {
+		end(err)
+		return
+	}
+	req.Header = m.Header
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.RemoteAddr = a.tunnel.conn.RemoteAddr().String()
+	resp, err := a.opts.Transport.RoundTrip(req)
+	if err != nil {
+		end(err)
+		return
+	}
+	defer resp.Body.Close()
+	// closing the body stops streams like followed logs when the hub cancels the request
+	stop := context.AfterFunc(ctx, func() { resp.Body.Close() })
+	defer stop()
+	if err := a.tunnel.write(message{Type: "response", ID: m.ID, Status: resp.StatusCode, Header: resp.Header}); err != nil {
+		return
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if werr := a.tunnel.write(message{Type: "data", ID: m.ID, Data: buf[:n]}); werr != nil {
+				return
+			}
+		}
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			end(nil)
+			return
+		}
+		if err != nil {
+			end(err)
+			return
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package hub
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tiredkangaroo/system/auth"
+)
+
+var ErrAgentNotFound = errors.New("agent not found")
+var ErrAgentExists = errors.New("agent already exists")
+var ErrInvalidAgentName = errors.New("agent names must be 1-64 characters of a-z, 0-9, '.', '_' and '-'")
+var ErrInvalidAgentToken = errors.New("invalid agent name or token")
+
+var agentNameRegexp = regexp.MustCompile(`^[a-z0-9_.-]{1,64}$`)
+
+const (
+	ModePull = "pull" // the hub connects to the agent
+	ModePush = "push" // the agent connects to the hub, for agents that can't be reached
+)
+
+// Agent is a server registered with the hub. In pull mode the hub polls it at URL with
+// Token, in push mode the agent connects to the hub with a token generated by the hub.
+type Agent struct {
+	Name      string    `json:"name"`
+	Mode      string    `json:"mode"`
+	URL       string    `json:"url,omitempty"`
+	Token     string    `json:"token,omitempty"`   // api token of the agent
+	CAFile    string    `json:"ca_file,omitempty"` // CA to verify the agent's certificate with
+	Insecure  bool      `json:"insecure,omitempty"`
+	TokenHash string    `json:"token_hash,omitempty"` // of the token a push agent connects with
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks the name, mode and URL of the agent.
+func (a Agent) Validate() error {
+	if !agentNameRegexp.MatchString(a.Name) {
+		return ErrInvalidAgentName
+	}
+	switch a.Mode {
+	case ModePull:
+	case ModePush:
+		if a.URL != "" || a.Token != "" || a.CAFile != "" {
+			return errors.New("push agents connect to the hub, so they don't have a url, token or ca_file")
+		}
+		return nil
+	default:
+		return fmt.Errorf("agent mode %q must be pull or push", a.Mode)
+	}
+	u, err := url.Parse(a.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("agent url %q must look like https://host:port", a.URL)
+	}
+	return nil
+}
+
+// AgentStore is the registry of agents, persisted as JSON in a file.
+type AgentStore struct {
+	path   string
+	mu     sync.RWMutex
+	agents []Agent
+}
+
+func (s *AgentStore) Get(name string) (Agent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := slices.IndexFunc(s.agents, func(a Agent) bool { return a.Name == name })
+	if i == -1 {
+		return Agent{}, ErrAgentNotFound
+	}
+	return s.agents[i], nil
+}
+
+// List returns every agent, without their tokens.
+func (s *AgentStore) List() []Agent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	agents := make([]Agent, len(s.agents))
+	for i, a := range s.agents {
+		a.Token, a.TokenHash = "", ""
+		agents[i] = a
+	}
+	return agents
+}
+
+// Create adds an agent, in pull mode if no mode is set. For push agents, it returns the
+// token the agent connects with, which is not stored.
+func (s *AgentStore) Create(a Agent) (string, error) {
+	if a.Mode == "" {
+		a.Mode = ModePull
+	}
+	if err := a.Validate(); err != nil {
+		return "", err
+	}
+	var token string
+	if a.Mode == ModePush {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		token = "sysagent_" + hex.EncodeToString(b)
+		a.TokenHash = auth.HashToken(token)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.agents, func(other Agent) bool { return other.Name == a.Name }) {
+		return "", ErrAgentExists
+	}
+	a.CreatedAt = time.Now()
+	s.agents = append(s.agents, a)
+	if err := s.save(); err != nil {
+		s.agents = s.agents[:len(s.agents)-1]
+		return "", err
+	}
+	return token, nil
+}
+
+// Authenticate checks the token a push agent connects with.
+func (s *AgentStore) Authenticate(name, token string) error {
+	a, err := s.Get(name)
+	if err != nil || a.Mode != ModePush || subtle.ConstantTimeCompare([]byte(a.TokenHash), []byte(auth.HashToken(token))) != 1 {
+		return ErrInvalidAgentToken
+	}
+	return nil
+}
+
+func (s *AgentStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.agents, func(a Agent) bool { return a.Name == name })
+	if i == -1 {
+		return ErrAgentNotFound
+	}
+	s.agents = slices.Delete(s.agents, i, i+1)
+	return s.save()
+}
+
+// save writes the agents atomically, readable only by the owner since they hold tokens.
+func (s *AgentStore) save() error {
+	data, err := json.MarshalIndent(s.agents, "", "  ")
+	if err != nil {
+		return err
+	}
+	return auth.WriteFileAtomic(s.path, data)
+}
+
+// NewAgentStore loads the agents stored at path. The file does not need to exist.
+func NewAgentStore(path string) (*AgentStore, error) {
+	s := &AgentStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.agents); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i := range s.agents {
+		if s.agents[i].Mode == "" {
+			s.agents[i].Mode = ModePull
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,34 @@
+package hub
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateRollsBackWhenSaveFails(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	s, err := NewAgentStore(filepath.Join(dir, "agents.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a file where the directory of the store should be makes every save fail
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(Agent{Name: "web", Mode: ModePush}); err == nil {
+		t.Fatal("Create succeeded without saving")
+	}
+	if _, err := s.Get("web"); !errors.Is(err, ErrAgentNotFound) {
+		t.Fatalf("Get after a failed Create = %v, want %v", err, ErrAgentNotFound)
+	}
+
+	// the name isn't taken once the store can be saved
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(Agent{Name: "web", Mode: ModePush}); err != nil {
+		t.Fatal(err)
+	}
+}
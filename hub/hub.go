@@ -0,0 +1,412 @@
+// Package hub keeps track of many agents (other servers) for a fleet-wide view: it polls
+// their system info, derives health and alerts from it and forwards api requests to them.
+package hub
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tiredkangaroo/system/system"
+)
+
+type Status string
+
+const (
+	StatusUnknown Status = "unknown" // not polled yet
+	StatusUp      Status = "up"
+	StatusDown    Status = "down"
+)
+
+// Thresholds are the usage percentages above which an alert is raised, disabled if 0.
+type Thresholds struct {
+	CPUPercent     float64
+	MemoryPercent  float64
+	StoragePercent float64
+}
+
+type Options struct {
+	PollInterval time.Duration
+	Timeout      time.Duration // of a poll and of forwarded requests that aren't streamed
+	Thresholds   Thresholds
+}
+
+// Host is the state of an agent as last seen by the hub.
+type Host struct {
+	Name     string     `json:"name"`
+	Mode     string     `json:"mode"`
+	URL      string     `json:"url,omitempty"`
+	Status   Status     `json:"status"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Summary  *Summary   `json:"summary,omitempty"`
+}
+
+// Summary is the health of a host at a glance.
+type Summary struct {
+	Hostname       string  `json:"hostname"`
+	OS             string  `json:"os"`
+	CPUUsage       float64 `json:"cpu_usage"`
+	MemoryPercent  float64 `json:"memory_percent"`
+	StoragePercent float64 `json:"storage_percent"`
+	Uptime         uint64  `json:"uptime"`
+	Processes      int     `json:"processes"`
+	FailedServices int     `json:"failed_services"`
+	Alerts         int     `json:"alerts"`
+}
+
+type Alert struct {
+	Host    string `json:"host"`
+	Kind    string `json:"kind"` // host_down, cpu, memory, storage or service_failed
+	Message string `json:"message"`
+}
+
+type host struct {
+	Host
+	info   *system.SystemInfo
+	target *target
+	tunnel *tunnel // of a connected push agent
+}
+
+// target is where the requests for an agent are sent.
+type target struct {
+	client *http.Client
+	base   string
+	token  string
+}
+
+// Hub polls the agents in its store.
+type Hub struct {
+	agents *AgentStore
+	opts   atomic.Pointer[Options]
+
+	mu    sync.RWMutex
+	hosts map[string]*host
+}
+
+func (h *Hub) SetOptions(opts Options) {
+	h.opts.Store(&opts)
+}
+
+// Run polls every agent each poll interval until ctx is done.
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		h.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.opts.Load().PollInterval):
+		}
+	}
+}
+
+// Poll fetches the system info of every pull agent once. Push agents send theirs.
+func (h *Hub) Poll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, agent := range h.agents.List() {
+		if agent.Mode == ModePush {
+			continue
+		}
+		t, err := h.target(agent.Name)
+		if err != nil {
+			h.setError(agent.Name, err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, h.opts.Load().Timeout)
+			defer cancel()
+			info, err := h.fetchInfo(ctx, t)
+			if err != nil {
+				h.setError(agent.Name, err)
+				return
+			}
+			h.setInfo(agent.Name, info)
+		}()
+	}
+	wg.Wait()
+}
+
+func (h *Hub) fetchInfo(ctx context.Context, t *target) (*system.SystemInfo, error) {
+	resp, err := t.do(ctx, http.MethodGet, "/api/v1/info", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get info: %s", resp.Status)
+	}
+	var info system.SystemInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("decode info: %w", err)
+	}
+	return &info, nil
+}
+
+// target returns where to send requests for an agent, creating the state of the agent
+// (and the client for a pull agent) if needed.
+func (h *Hub) target(name string) (*target, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hst, ok := h.hosts[name]
+	if ok && hst.target != nil {
+		return hst.target, nil
+	}
+	agent, err := h.agents.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		hst = newHost(agent)
+		h.hosts[name] = hst
+	}
+	if agent.Mode == ModePush {
+		return nil, ErrAgentNotConnected
+	}
+	client, err := newClient(agent, h.opts.Load().Timeout)
+	if err != nil {
+		return nil, err
+	}
+	hst.target = &target{client: client, base: strings.TrimRight(agent.URL, "/"), token: agent.Token}
+	return hst.target, nil
+}
+
+func newHost(agent Agent) *host {
+	return &host{Host: Host{Name: agent.Name, Mode: agent.Mode, URL: agent.URL, Status: StatusUnknown}}
+}
+
+// newClient returns a client for the agent. timeout only applies until the response
+// headers are received, so that streamed responses like followed logs are not cut off.
+func newClient(agent Agent, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: agent.Insecure}
+	if agent.CAFile != "" {
+		pem, err := os.ReadFile(agent.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", agent.CAFile)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+func (h *Hub) setInfo(name string, info *system.SystemInfo) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hst, ok := h.hosts[name]; ok {
+		now := time.Now()
+		hst.Status, hst.LastSeen, hst.Error, hst.info = StatusUp, &now, "", info
+	}
+}
+
+func (h *Hub) setError(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hst, ok := h.hosts[name]; ok {
+		hst.Status, hst.Error = StatusDown, err.Error()
+	}
+}
+
+// Register adds an agent and polls it if it's a pull agent. For push agents, it returns
+// the token the agent connects with.
+func (h *Hub) Register(ctx context.Context, agent Agent) (string, error) {
+	if agent.Mode != ModePush {
+		if _, err := newClient(agent, 0); err != nil {
+			return "", err
+		}
+	}
+	token, err := h.agents.Create(agent)
+	if err != nil {
+		return "", err
+	}
+	t, err := h.target(agent.Name)
+	if errors.Is(err, ErrAgentNotConnected) {
+		return token, nil
+	} else if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.opts.Load().Timeout)
+	defer cancel()
+	if info, err := h.fetchInfo(ctx, t); err != nil {
+		h.setError(agent.Name, err)
+	} else {
+		h.setInfo(agent.Name, info)
+	}
+	return "", nil
+}
+
+func (h *Hub) Unregister(name string) error {
+	if err := h.agents.Delete(name); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	if hst, ok := h.hosts[name]; ok && hst.tunnel != nil {
+		hst.tunnel.close(ErrAgentNotFound)
+	}
+	delete(h.hosts, name)
+	h.mu.Unlock()
+	return nil
+}
+
+// Agents returns the registered agents, without their tokens.
+func (h *Hub) Agents() []Agent {
+	return h.agents.List()
+}
+
+// Hosts returns the state of every agent, sorted by name.
+func (h *Hub) Hosts() []Host {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	hosts := make([]Host, 0, len(h.hosts))
+	for _, hst := range h.hosts {
+		hosts = append(hosts, h.snapshot(hst))
+	}
+	slices.SortFunc(hosts, func(a, b Host) int { return strings.Compare(a.Name, b.Name) })
+	return hosts
+}
+
+// Host returns the state of an agent and the system info it last reported.
+func (h *Hub) Host(name string) (Host, *system.SystemInfo, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	hst, ok := h.hosts[name]
+	if !ok {
+		return Host{}, nil, ErrAgentNotFound
+	}
+	return h.snapshot(hst), hst.info, nil
+}
+
+// snapshot copies the state of hst with its summary. h.mu must be held.
+func (h *Hub) snapshot(hst *host) Host {
+	s := hst.Host
+	if hst.info != nil {
+		info := hst.info
+		s.Summary = &Summary{
+			Hostname:       info.Hostname,
+			OS:             strings.TrimSpace(info.OS + " " + info.OSRelease),
+			CPUUsage:       info.CPU_Usage,
+			MemoryPercent:  percent(info.MemoryUsed, info.Memory),
+			StoragePercent: percent(info.StorageUsed, info.StorageCapacity),
+			Uptime:         info.Uptime,
+			Processes:      len(info.Processes),
+			Alerts:         len(h.alerts(hst)),
+		}
+		for _, svc := range info.Services {
+			if svc.Status == "failed" {
+				s.Summary.FailedServices++
+			}
+		}
+	}
+	return s
+}
+
+// Alerts returns the alerts of every agent, sorted by host.
+func (h *Hub) Alerts() []Alert {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	alerts := []Alert{}
+	for _, hst := range h.hosts {
+		alerts = append(alerts, h.alerts(hst)...)
+	}
+	slices.SortStableFunc(alerts, func(a, b Alert) int { return strings.Compare(a.Host, b.Host) })
+	return alerts
+}
+
+// alerts derives the alerts of a host from its state. h.mu must be held.
+func (h *Hub) alerts(hst *host) []Alert {
+	if hst.Status == StatusDown {
+		return []Alert{{Host: hst.Name, Kind: "host_down", Message: "host is unreachable: " + hst.Error}}
+	}
+	if hst.info == nil {
+		return nil
+	}
+	return h.opts.Load().Thresholds.Check(hst.Name, hst.info)
+}
+
+// HostAlerts returns the alerts of an agent.
+func (h *Hub) HostAlerts(name string) ([]Alert, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	hst, ok := h.hosts[name]
+	if !ok {
+		return nil, ErrAgentNotFound
+	}
+	return h.alerts(hst), nil
+}
+
+// Check returns the alerts raised by info, reported as coming from host.
+func (t Thresholds) Check(host string, info *system.SystemInfo) []Alert {
+	alerts := []Alert{}
+	check := func(kind string, value, threshold float64) {
+		if threshold > 0 && value >= threshold {
+			alerts = append(alerts, Alert{Host: host, Kind: kind, Message: fmt.Sprintf("%s usage is %.1f%% (threshold %.0f%%)", kind, value, threshold)})
+		}
+	}
+	check("cpu", info.CPU_Usage, t.CPUPercent)
+	check("memory", percent(info.MemoryUsed, info.Memory), t.MemoryPercent)
+	check("storage", percent(info.StorageUsed, info.StorageCapacity), t.StoragePercent)
+	for _, svc := range info.Services {
+		if svc.Status == "failed" {
+			alerts = append(alerts, Alert{Host: host, Kind: "service_failed", Message: svc.Name + " has failed"})
+		}
+	}
+	return alerts
+}
+
+// Do sends a request for path (e.g. /api/v1/service/nginx/logs?follow=true) to an agent
+// with its credentials. The caller closes the body of the response.
+func (h *Hub) Do(ctx context.Context, name, method, path string, header http.Header, body io.Reader) (*http.Response, error) {
+	t, err := h.target(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.do(ctx, method, path, header, body)
+}
+
+func (t *target) do(ctx context.Context, method, path string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return t.client.Do(req)
+}
+
+func percent(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) / float64(total) * 100
+}
+
+// New returns a hub for the agents in store. Call Run to start polling them.
+func New(agents *AgentStore, opts Options) *Hub {
+	h := &Hub{agents: agents, hosts: make(map[string]*host)}
+	h.SetOptions(opts)
+	for _, agent := range agents.List() {
+		h.hosts[agent.Name] = newHost(agent)
+	}
+	return h
+}
@@ -0,0 +1,351 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/tiredkangaroo/system/system"
+)
+
+var ErrAgentNotConnected = errors.New("agent is not connected")
+var errSlowReader = errors.New("response cancelled, it was not read fast enough")
+
+const (
+	pingInterval = 30 * time.Second
+	readTimeout  = 3 * pingInterval // without a message or pong from the other side
+	writeTimeout = 10 * time.Second
+	streamBuffer = 64 // data messages (of up to 32 KiB) buffered for each response
+)
+
+// message is sent over the websocket between a push agent and the hub, as JSON. the agent
+// sends info messages on its own, the hub sends requests that the agent answers with a
+// response, any number of data messages and an end.
+type message struct {
+	Type   string             `json:"type"` // info, request, cancel, response, data or end
+	ID     uint64             `json:"id,omitempty"`
+	Method string             `json:"method,omitempty"`
+	Path   string             `json:"path,omitempty"`
+	Header http.Header        `json:"header,omitempty"`
+	Status int                `json:"status,omitempty"`
+	Data   []byte             `json:"data,omitempty"` // request or response body
+	Error  string             `json:"error,omitempty"`
+	Info   *system.SystemInfo `json:"info,omitempty"`
+}
+
+// tunnel sends requests to a push agent over its websocket. It is the transport of the
+// client for the agent.
+type tunnel struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]*tunnelStream
+	closed  chan struct{}
+	err     error
+}
+
+type tunnelStream struct {
+	result    chan tunnelResult
+	responded bool
+	body      *io.PipeWriter
+
+	data chan []byte   // received, not yet written to body
+	done chan struct{} // closed once the request has ended
+	err  error         // the request ended with, set before done is closed
+	once sync.Once
+}
+
+// copy writes the data of the response to its body as it's read, so that a slow reader
+// only holds up its own response. Once the request has ended, the rest of the data is
+// written and the body is closed.
+func (s *tunnelStream) copy() {
+	for {
+		select {
+		case data := <-s.data:
+			s.body.Write(data) // fails once the body is closed, and the next data too
+		case <-s.done:
+			for {
+				select {
+				case data := <-s.data:
+					s.body.Write(data)
+				default:
+					s.body.CloseWithError(s.err)
+					return
+				}
+			}
+		}
+	}
+}
+
+// end ends the body with err, or with io.EOF if err is nil, after the data received
+// before it.
+func (s *tunnelStream) end(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+type tunnelResult struct {
+	resp *http.Response
+	err  error
+}
+
+func newTunnel(conn *websocket.Conn) *tunnel {
+	return &tunnel{conn: conn, pending: make(map[uint64]*tunnelStream), closed: make(chan struct{})}
+}
+
+func (t *tunnel) write(m message) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	t.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return t.conn.WriteJSON(m)
+}
+
+func (t *tunnel) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	pr, pw := io.Pipe()
+	s := &tunnelStream{result: make(chan tunnelResult, 1), body: pw, data: make(chan []byte, streamBuffer), done: make(chan struct{})}
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return nil, t.err
+	}
+	t.nextID++
+	id := t.nextID
+	t.pending[id] = s
+	t.mu.Unlock()
+	go s.copy()
+
+	err := t.write(message{Type: "request", ID: id, Method: req.Method, Path: req.URL.RequestURI(), Header: req.Header, Data: body})
+	if err != nil {
+		t.abort(id, err)
+		return nil, err
+	}
+	select {
+	case r := <-s.result:
+		if r.err != nil {
+			return nil, r.err
+		}
+		b := &tunnelBody{PipeReader: pr, t: t, id: id}
+		b.stop = context.AfterFunc(req.Context(), func() { b.Close() })
+		r.resp.Body, r.resp.Request = b, req
+		return r.resp, nil
+	case <-req.Context().Done():
+		t.cancel(id)
+		return nil, req.Context().Err()
+	}
+}
+
+// tunnelBody is the body of a response from a push agent. Closing it before the end
+// cancels the request on the agent, e.g. to stop following logs.
+type tunnelBody struct {
+	*io.PipeReader
+	t    *tunnel
+	id   uint64
+	stop func() bool
+	once sync.Once
+}
+
+func (b *tunnelBody) Close() error {
+	b.once.Do(func() {
+		b.stop()
+		b.PipeReader.Close()
+		b.t.cancel(b.id)
+	})
+	return nil
+}
+
+// cancel stops a request that hasn't ended yet.
+func (t *tunnel) cancel(id uint64) {
+	if t.abort(id, context.Canceled) {
+		t.write(message{Type: "cancel", ID: id})
+	}
+}
+
+// finish ends a request with err, or with io.EOF if err is nil, once the data received
+// before it has been read.
+func (t *tunnel) finish(id uint64, err error) {
+	if s := t.remove(id, err); s != nil {
+		s.end(err)
+	}
+}
+
+// abort ends a request with err right away, dropping the data that hasn't been read. It
+// reports whether the request hadn't ended yet.
+func (t *tunnel) abort(id uint64, err error) bool {
+	s := t.remove(id, err)
+	if s == nil {
+		return false
+	}
+	s.body.CloseWithError(err)
+	s.end(err)
+	return true
+}
+
+// remove forgets a request, failing it with err if it has no response yet.
+func (t *tunnel) remove(id uint64, err error) *tunnelStream {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.pending[id]
+	if !ok {
+		return nil
+	}
+	delete(t.pending, id)
+	if !s.responded {
+		if err == nil {
+			err = errors.New("agent ended the request without a response")
+		}
+		s.result <- tunnelResult{err: err}
+	}
+	return s
+}
+
+// close ends every request with err and closes the websocket.
+func (t *tunnel) close(err error) {
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return
+	}
+	t.err = err
+	close(t.closed)
+	ids := make([]uint64, 0, len(t.pending))
+	for id := range t.pending {
+		ids = append(ids, id)
+	}
+	t.mu.Unlock()
+	for _, id := range ids {
+		t.abort(id, err)
+	}
+	t.conn.Close()
+}
+
+// run reads messages from the agent until the websocket fails. It never waits for the
+// reader of a response, a response that isn't read fast enough to keep up with the agent
+// is cancelled instead.
+func (t *tunnel) run(onInfo func(*system.SystemInfo)) error {
+	t.conn.SetReadDeadline(time.Now().Add(readTimeout))
+	t.conn.SetPongHandler(func(string) error {
+		return t.conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.closed:
+				return
+			case <-ticker.C:
+				t.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+			}
+		}
+	}()
+	for {
+		var m message
+		if err := t.conn.ReadJSON(&m); err != nil {
+			return err
+		}
+		t.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		switch m.Type {
+		case "info":
+			if m.Info != nil {
+				onInfo(m.Info)
+			}
+		case "response":
+			t.mu.Lock()
+			s, ok := t.pending[m.ID]
+			if ok && !s.responded {
+				s.responded = true
+				s.result <- tunnelResult{resp: &http.Response{
+					Status:        fmt.Sprintf("%d %s", m.Status, http.StatusText(m.Status)),
+					StatusCode:    m.Status,
+					Proto:         "HTTP/1.1",
+					ProtoMajor:    1,
+					ProtoMinor:    1,
+					Header:        m.Header,
+					ContentLength: -1,
+				}}
+			}
+			t.mu.Unlock()
+		case "data":
+			t.mu.Lock()
+			s, ok := t.pending[m.ID]
+			t.mu.Unlock()
+			if ok {
+				select {
+				case s.data <- m.Data:
+				default:
+					// the reader stopped reading (e.g: a client following logs), waiting for
+					// it would stop reading pongs too and disconnect the agent
+					t.abort(m.ID, errSlowReader)
+					go t.write(message{Type: "cancel", ID: m.ID})
+				}
+			}
+		case "end":
+			var err error
+			if m.Error != "" {
+				err = errors.New(m.Error)
+			}
+			t.finish(m.ID, err)
+		}
+	}
+}
+
+// Authenticate checks the name and token a push agent connects with.
+func (h *Hub) Authenticate(name, token string) error {
+	return h.agents.Authenticate(name, token)
+}
+
+// Attach serves the websocket of a push agent until it disconnects or ctx is done. A
+// previous connection of the agent is closed.
+func (h *Hub) Attach(ctx context.Context, name string, conn *websocket.Conn) error {
+	agent, err := h.agents.Get(name)
+	if err != nil {
+		return err
+	}
+	t := newTunnel(conn)
+	h.mu.Lock()
+	hst, ok := h.hosts[name]
+	if !ok {
+		hst = newHost(agent)
+		h.hosts[name] = hst
+	}
+	if hst.tunnel != nil {
+		hst.tunnel.close(errors.New("agent connected again"))
+	}
+	hst.tunnel = t
+	hst.target = &target{client: &http.Client{Transport: t}, base: "http://" + name}
+	h.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, func() {
+		t.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "hub is shutting down"), time.Now().Add(time.Second))
+		t.close(ErrAgentNotConnected)
+	})
+	defer stop()
+	err = t.run(func(info *system.SystemInfo) { h.setInfo(name, info) })
+	t.close(ErrAgentNotConnected)
+
+	h.mu.Lock()
+	if hst.tunnel == t {
+		hst.tunnel, hst.target = nil, nil
+		hst.Status, hst.Error = StatusDown, "agent disconnected"
+	}
+	h.mu.Unlock()
+	return err
+}
@@ -0,0 +1,89 @@
+package hub
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/websocket"
+	"github.com/tiredkangaroo/system/system"
+)
+
+// newTestTunnel returns a tunnel and the websocket of the agent at the other end of it.
+func newTestTunnel(t *testing.T) (*tunnel, *websocket.Conn) {
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			conns <- conn
+		}
+	}))
+	t.Cleanup(srv.Close)
+	agent, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tun := newTunnel(<-conns)
+	go tun.run(func(*system.SystemInfo) {})
+	t.Cleanup(func() {
+		tun.close(ErrAgentNotConnected)
+		agent.Close()
+	})
+	return tun, agent
+}
+
+func TestSlowReaderOnlyCancelsItsResponse(t *testing.T) {
+	tun, agent := newTestTunnel(t)
+	client := &http.Client{Transport: tun}
+	get := func(path string) <-chan *http.Response {
+		responses := make(chan *http.Response, 1)
+		go func() {
+			resp, err := client.Get("http://agent" + path)
+			if err != nil {
+				t.Error(err)
+			}
+			responses <- resp
+		}()
+		return responses
+	}
+	read := func() message {
+		var m message
+		if err := agent.ReadJSON(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	// the body of the followed logs is never read
+	responses := get("/api/v1/system/logs?follow=true")
+	logs := read()
+	agent.WriteJSON(message{Type: "response", ID: logs.ID, Status: http.StatusOK})
+	resp := <-responses
+	for range streamBuffer + 2 {
+		if err := agent.WriteJSON(message{Type: "data", ID: logs.ID, Data: []byte("line\n")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m := read(); m.Type != "cancel" || m.ID != logs.ID {
+		t.Fatalf("message = %+v, want the logs cancelled", m)
+	}
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, errSlowReader) {
+		t.Fatalf("reading the logs = %v, want %v", err, errSlowReader)
+	}
+	resp.Body.Close()
+
+	// the other requests to the agent still work
+	responses = get("/api/v1/info")
+	info := read()
+	agent.WriteJSON(message{Type: "response", ID: info.ID, Status: http.StatusOK})
+	agent.WriteJSON(message{Type: "data", ID: info.ID, Data: []byte("{}")})
+	agent.WriteJSON(message{Type: "end", ID: info.ID})
+	resp = <-responses
+	defer resp.Body.Close()
+	if body, err := io.ReadAll(resp.Body); err != nil || string(body) != "{}" {
+		t.Fatalf("info = %q, %v", body, err)
+	}
+}
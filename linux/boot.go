@@ -1,6 +1,14 @@
 package linux
 
-import "os/exec"
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/tiredkangaroo/system/system"
+)
 
 func (ls *LinuxSystem) Shutdown() error {
 	return exec.Command("systemctl", "poweroff").Run()
@@ -8,3 +16,90 @@ func (ls *LinuxSystem) Shutdown() error {
 func (ls *LinuxSystem) Reboot() error {
 	return exec.Command("systemctl", "reboot").Run()
 }
+func (ls *LinuxSystem) Suspend() error {
+	return runSleepCmd("suspend")
+}
+func (ls *LinuxSystem) Hibernate() error {
+	return runSleepCmd("hibernate")
+}
+func (ls *LinuxSystem) HybridSleep() error {
+	return runSleepCmd("hybrid-sleep")
+}
+func (ls *LinuxSystem) SuspendThenHibernate() error {
+	return runSleepCmd("suspend-then-hibernate")
+}
+
+// WallMessage broadcasts message to the terminals of all logged-in users.
+func (ls *LinuxSystem) WallMessage(message string) error {
+	cmd := exec.Command("wall")
+	cmd.Stdin = strings.NewReader(message)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wall: %s, output: %s", err.Error(), string(output))
+	}
+	return nil
+}
+
+func runSleepCmd(verb string) error {
+	output, err := exec.Command("systemctl", verb).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s, output: %s", verb, err.Error(), string(output))
+	}
+	return nil
+}
+
+// getSupportedPowerActions reports the power actions the host supports. sleep states
+// must be offered by the kernel in /sys/power/state and, if logind can be reached,
+// allowed by its CanSuspend/CanHibernate/... methods.
+func getSupportedPowerActions() []system.PowerAction {
+	actions := []system.PowerAction{system.PowerActionShutdown, system.PowerActionReboot}
+
+	data, err := os.ReadFile("/sys/power/state")
+	if err != nil {
+		return actions
+	}
+	states := strings.Fields(string(data))
+	canMem := slices.Contains(states, "mem") || slices.Contains(states, "freeze")
+	canDisk := slices.Contains(states, "disk")
+
+	candidates := []struct {
+		action       system.PowerAction
+		logindMethod string
+		kernel       bool
+	}{
+		{system.PowerActionSuspend, "CanSuspend", canMem},
+		{system.PowerActionHibernate, "CanHibernate", canDisk},
+		{system.PowerActionHybridSleep, "CanHybridSleep", canMem && canDisk},
+		{system.PowerActionSuspendThenHibernate, "CanSuspendThenHibernate", canMem && canDisk},
+	}
+	for _, c := range candidates {
+		if !c.kernel {
+			continue
+		}
+		if allowed, err := logindCan(c.logindMethod); err == nil && !allowed {
+			continue
+		}
+		actions = append(actions, c.action)
+	}
+	return actions
+}
+
+// logindCan calls one of logind's Can* methods (e.g: CanSuspend) over D-Bus.
+func logindCan(method string) (bool, error) {
+	output, err := exec.Command("busctl", "call", "org.freedesktop.login1", "/org/freedesktop/login1",
+		"org.freedesktop.login1.Manager", method).Output()
+	if err != nil {
+		return false, err
+	}
+	// output looks like: s "yes"
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return false, fmt.Errorf("malformed busctl output: %s", string(output))
+	}
+	switch strings.Trim(fields[1], `"`) {
+	case "yes", "challenge":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
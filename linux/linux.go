@@ -7,31 +7,42 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/host"
 	"github.com/shirou/gopsutil/v4/mem"
-	"github.com/shirou/gopsutil/v4/process"
 	"github.com/tiredkangaroo/system/system"
 )
 
-type LinuxSystem struct{}
+type LinuxSystem struct {
+	processes processSampler
+	cpu       cpuSampler
+}
 
-func (ls *LinuxSystem) GetSystemInfo() (*system.SystemInfo, error) {
-	staticInfo, err := getStaticSysInfo()
-	if err != nil {
-		return nil, err
-	}
-	dynamicInfo, err := getDynamicSysInfo(staticInfo.HasBattery)
-	if err != nil {
-		return nil, err
-	}
-	return &system.SystemInfo{
-		StaticInfo:  staticInfo,
-		DynamicInfo: dynamicInfo,
-	}, nil
+func (ls *LinuxSystem) GetStaticInfo() (system.StaticInfo, error) {
+	return getStaticSysInfo()
+}
+
+func (ls *LinuxSystem) GetMetrics(fields system.Fields) (system.Metrics, error) {
+	return getMetrics(fields, &ls.cpu)
+}
+
+func (ls *LinuxSystem) GetProcesses() ([]system.Process, error) {
+	return ls.processes.sample()
 }
+
+func (ls *LinuxSystem) SignalProcess(pid int32, signal syscall.Signal) error {
+	return syscall.Kill(int(pid), signal)
+}
+
+func (ls *LinuxSystem) GetServices() ([]system.Service, error) {
+	return getCurrentServices()
+}
+
 func (ls *LinuxSystem) GetSystemLogs(logOptions system.LogOptions) (io.ReadCloser, error) {
 	// journalctl --since=@<timestamp> --until=@<timestamp>
 	a := ls.buildLogArgs(logOptions)
@@ -65,70 +76,110 @@ func getStaticSysInfo() (system.StaticInfo, error) {
 	// hasbattery, battery
 	info.HasBattery, info.Battery = getBatteryInfo() // battery info
 
+	info.PowerActions = getSupportedPowerActions() // shutdown, reboot, suspend, etc.
+
 	return info, nil
 }
 
-func getDynamicSysInfo(hasBattery bool) (system.DynamicInfo, error) {
-	var info system.DynamicInfo
-	var err error
+// minCPUWindow is the shortest time cpu usage is measured over. metrics are collected again
+// right away when a collector is woken up, and cpu usage since the previous collection would
+// be meaningless a few milliseconds later.
+const minCPUWindow = 250 * time.Millisecond
 
-	usages, err := cpu.Percent(0, false)
+// cpuSampler measures cpu usage since the previous sample.
+type cpuSampler struct {
+	mu sync.Mutex
+	at time.Time // when the previous sample was taken
+}
+
+func (c *cpuSampler) usage() (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var usages []float64
+	var err error
+	if time.Since(c.at) < minCPUWindow {
+		usages, err = cpu.Percent(minCPUWindow, false) // doesn't replace the previous sample
+	} else {
+		usages, err = cpu.Percent(0, false)
+		c.at = time.Now()
+	}
 	if err != nil {
-		return info, err
+		return 0, err
 	}
-	info.CPU_Usage = usages[0] // cpu usage percentage
+	return usages[0], nil
+}
 
-	info.CPU_Temp, err = getCPUTemp()
-	if err != nil {
-		slog.Error("cannot get cpu temperature", "error", err)
+// getMetrics reads only the metrics in fields.
+func getMetrics(fields system.Fields, sampler *cpuSampler) (system.Metrics, error) {
+	var info system.Metrics
+	var err error
+
+	if fields.Has(system.FieldCPUUsage) {
+		info.CPU_Usage, err = sampler.usage() // cpu usage percentage
+		if err != nil {
+			return info, err
+		}
 	}
 
-	memstat, err := mem.VirtualMemory()
-	if err != nil {
-		return info, err
+	if fields.Has(system.FieldCPUTemp) {
+		info.CPU_Temp, err = getCPUTemp()
+		if err != nil {
+			slog.Error("cannot get cpu temperature", "error", err)
+		}
 	}
-	info.MemoryUsed = memstat.Used
 
-	diskstat, err := disk.Usage("/")
-	if err != nil {
-		return info, err
+	if fields.Has(system.FieldMemoryUsed) {
+		memstat, err := mem.VirtualMemory()
+		if err != nil {
+			return info, err
+		}
+		info.MemoryUsed = memstat.Used
+	}
+
+	if fields.Has(system.FieldStorageUsed) {
+		diskstat, err := disk.Usage("/")
+		if err != nil {
+			return info, err
+		}
+		info.StorageUsed = diskstat.Used
 	}
-	info.StorageUsed = diskstat.Used
 
+	hasBattery := false
+	if fields&system.BatteryFields != 0 {
+		hasBattery, _ = getBatteryInfo()
+	}
 	if hasBattery {
 		// /sys/class/power_supply/BAT0/temp
-		data, err := os.ReadFile("/sys/class/power_supply/BAT0/temp")
-		if err == nil {
-			temp, _ := strconv.Atoi(strings.TrimSpace(string(data)))
-			tempCelsius := float64(temp) / 10.0
-			info.BatteryTemp = tempCelsius
+		if fields.Has(system.FieldBatteryTemp) {
+			data, err := os.ReadFile("/sys/class/power_supply/BAT0/temp")
+			if err == nil {
+				temp, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+				tempCelsius := float64(temp) / 10.0
+				info.BatteryTemp = tempCelsius
+			}
 		}
 		// /sys/class/power_supply/BAT0/capacity
-		data, err = os.ReadFile("/sys/class/power_supply/BAT0/capacity")
-		if err == nil {
-			capacity, _ := strconv.Atoi(strings.TrimSpace(string(data)))
-			info.BatteryPercent = float64(capacity)
+		if fields.Has(system.FieldBatteryPercent) {
+			data, err := os.ReadFile("/sys/class/power_supply/BAT0/capacity")
+			if err == nil {
+				capacity, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+				info.BatteryPercent = float64(capacity)
+			}
 		}
 		// /sys/class/power_supply/BAT0/status
-		data, err = os.ReadFile("/sys/class/power_supply/BAT0/status")
-		if err == nil {
-			info.BatteryStatus = strings.TrimSpace(string(data))
+		if fields.Has(system.FieldBatteryStatus) {
+			data, err := os.ReadFile("/sys/class/power_supply/BAT0/status")
+			if err == nil {
+				info.BatteryStatus = strings.TrimSpace(string(data))
+			}
 		}
 	}
 
-	info.Processes, err = getCurrentProcesses()
-	if err != nil {
-		slog.Error("cannot get host processes", "error", err)
-	}
-
-	info.Uptime, err = host.Uptime()
-	if err != nil {
-		slog.Error("cannot get host uptime", "error", err)
-	}
-
-	info.Services, err = getCurrentServices()
-	if err != nil {
-		slog.Error("cannot get services", "error", err)
+	if fields.Has(system.FieldUptime) {
+		info.Uptime, err = host.Uptime()
+		if err != nil {
+			slog.Error("cannot get host uptime", "error", err)
+		}
 	}
 	return info, nil
 }
@@ -145,49 +196,6 @@ func getCPUTemp() (float64, error) {
 	return float64(temp) / 1000.0, nil
 }
 
-func getCurrentProcesses() ([]system.Process, error) {
-	processes, err := process.Processes()
-	if err != nil {
-		return nil, err
-	}
-	infoProcesses := make([]system.Process, 0, len(processes))
-	for _, p := range processes {
-		name, err := p.Name()
-		if err != nil {
-			continue
-		}
-		status, err := p.Status()
-		if err != nil || len(status) == 0 {
-			continue
-		}
-		parentPID := int32(-1)
-		if p, err := p.Parent(); err == nil {
-			parentPID = p.Pid
-		}
-		var childrenPIDs []int32
-		children, err := p.Children()
-		if err == nil {
-			childrenPIDs = make([]int32, 0, len(children))
-			for _, c := range children {
-				childrenPIDs = append(childrenPIDs, c.Pid)
-			}
-		}
-
-		infoProcesses = append(infoProcesses, system.Process{
-			PID:           p.Pid,
-			Name:          name,
-			Status:        status[0],
-			Threads:       numOrNegOne(p.NumThreads()),
-			CPUPercent:    numOrNegOne(p.CPUPercent()),
-			MemoryPercent: numOrNegOne(p.MemoryPercent()),
-			ParentPID:     parentPID,
-			NumFDs:        numOrNegOne(p.NumFDs()),
-			ChildrenPIDs:  childrenPIDs,
-		})
-	}
-	return infoProcesses, nil
-}
-
 func getBatteryInfo() (bool, string) {
 	base := "/sys/class/power_supply/"
 	entries, err := os.ReadDir(base)
@@ -0,0 +1,121 @@
+package linux
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/tiredkangaroo/system/system"
+)
+
+// processSampler keeps the counters of every process between samples, so that cpu usage,
+// disk i/o and context switches are reported since the previous sample (like top does)
+// instead of averaged over the lifetime of the process.
+type processSampler struct {
+	mu   sync.Mutex
+	prev map[int32]processCounters
+}
+
+type processCounters struct {
+	created int64     // ms since the epoch, tells a new process reusing a pid apart
+	at      time.Time // when the counters were read
+
+	cpu           float64 // user and system time in seconds
+	read, written uint64  // bytes from and to storage
+	vol, invol    int64   // context switches
+
+	cpuOK, ioOK, switchesOK bool // whether the counters could be read
+}
+
+func (ps *processSampler) sample() ([]system.Process, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	numCPU := float64(runtime.NumCPU())
+	counters := make(map[int32]processCounters, len(processes))
+	infoProcesses := make([]system.Process, 0, len(processes))
+	for _, p := range processes {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		status, err := p.Status()
+		if err != nil || len(status) == 0 {
+			continue
+		}
+		parentPID := int32(-1)
+		if p, err := p.Parent(); err == nil {
+			parentPID = p.Pid
+		}
+		var childrenPIDs []int32
+		children, err := p.Children()
+		if err == nil {
+			childrenPIDs = make([]int32, 0, len(children))
+			for _, c := range children {
+				childrenPIDs = append(childrenPIDs, c.Pid)
+			}
+		}
+
+		cur := processCounters{at: time.Now()}
+		cur.created, _ = p.CreateTime()
+		prev, ok := ps.prev[p.Pid]
+		if !ok || prev.created != cur.created {
+			// first sample of the process: the rates are unknown (-1) until the next one
+			prev = processCounters{}
+		}
+		elapsed := cur.at.Sub(prev.at).Seconds()
+
+		info := system.Process{
+			PID:                      p.Pid,
+			Name:                     name,
+			Status:                   status[0],
+			Threads:                  numOrNegOne(p.NumThreads()),
+			CPUPercent:               -1,
+			MemoryPercent:            numOrNegOne(p.MemoryPercent()),
+			ParentPID:                parentPID,
+			NumFDs:                   numOrNegOne(p.NumFDs()),
+			ChildrenPIDs:             childrenPIDs,
+			DiskReadRate:             -1,
+			DiskWriteRate:            -1,
+			VoluntaryCtxSwitchRate:   -1,
+			InvoluntaryCtxSwitchRate: -1,
+		}
+		if times, err := p.Times(); err == nil {
+			cur.cpu, cur.cpuOK = times.User+times.System, true
+			if prev.cpuOK {
+				info.CPUPercent = rate(cur.cpu-prev.cpu, elapsed) * 100 / numCPU
+			}
+		}
+		// other users' processes need root
+		if io, err := p.IOCounters(); err == nil {
+			cur.read, cur.written, cur.ioOK = io.DiskReadBytes, io.DiskWriteBytes, true
+			if prev.ioOK {
+				info.DiskReadRate = rate(float64(cur.read)-float64(prev.read), elapsed)
+				info.DiskWriteRate = rate(float64(cur.written)-float64(prev.written), elapsed)
+			}
+		}
+		if switches, err := p.NumCtxSwitches(); err == nil {
+			cur.vol, cur.invol, cur.switchesOK = switches.Voluntary, switches.Involuntary, true
+			if prev.switchesOK {
+				info.VoluntaryCtxSwitchRate = rate(float64(cur.vol-prev.vol), elapsed)
+				info.InvoluntaryCtxSwitchRate = rate(float64(cur.invol-prev.invol), elapsed)
+			}
+		}
+		counters[p.Pid] = cur
+		infoProcesses = append(infoProcesses, info)
+	}
+	ps.prev = counters // exited processes are forgotten
+	return infoProcesses, nil
+}
+
+// rate returns delta per second, 0 if it can't be computed.
+func rate(delta, seconds float64) float64 {
+	if seconds <= 0 || delta < 0 {
+		return 0
+	}
+	return delta / seconds
+}
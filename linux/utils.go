@@ -27,7 +27,7 @@ func fieldValueFromProcFile(filename string, field string) (string, error) {
 	return "unknown", fmt.Errorf("field %s not found in %s", field, filename)
 }
 
-func (ls LinuxSystem) buildLogArgs(logOptions system.LogOptions) []string {
+func (ls *LinuxSystem) buildLogArgs(logOptions system.LogOptions) []string {
 	a := []string{"-o", "short-full"}
 	if logOptions.ThisBootOnly {
 		a = append(a, "-b")
@@ -38,6 +38,12 @@ func (ls LinuxSystem) buildLogArgs(logOptions system.LogOptions) []string {
 	if logOptions.Until != nil {
 		a = append(a, fmt.Sprintf("--until=@%d", logOptions.Until.Unix()))
 	}
+	if logOptions.Lines > 0 {
+		a = append(a, fmt.Sprintf("--lines=%d", logOptions.Lines))
+	}
+	if logOptions.Follow {
+		a = append(a, "--follow")
+	}
 	return a
 }
 func (ls *LinuxSystem) runCmdGetPipe(cmdName string, args ...string) (io.ReadCloser, error) {
@@ -49,7 +55,21 @@ func (ls *LinuxSystem) runCmdGetPipe(cmdName string, args ...string) (io.ReadClo
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
-	return pipe, nil
+	return &cmdPipe{ReadCloser: pipe, cmd: cmd}, nil
+}
+
+// cmdPipe is the stdout of a running command. closing it kills the command if it is still
+// running and waits for it, so that it doesn't outlive the request reading its output.
+type cmdPipe struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (p *cmdPipe) Close() error {
+	p.cmd.Process.Kill()
+	err := p.ReadCloser.Close()
+	p.cmd.Wait()
+	return err
 }
 
 func numOrNegOne[T int8 | int16 | int32 | int64 | float32 | float64](v T, err error) T {
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/config"
+)
+
+// listen opens every configured listener. TCP listeners serve TLS if tlsConfig is not nil,
+// unix sockets never do.
+func listen(tlsConfig *tls.Config) ([]net.Listener, error) {
+	var listeners []net.Listener
+	for _, l := range conf().ListenerConfigs() {
+		var ln net.Listener
+		var err error
+		if l.Network == "unix" {
+			ln, err = listenUnix(l)
+		} else {
+			ln, err = net.Listen(l.Network, l.Address)
+			if err == nil && tlsConfig != nil {
+				ln = tls.NewListener(ln, tlsConfig)
+			}
+		}
+		if err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+			return nil, fmt.Errorf("%s %s: %w", l.Network, l.Address, err)
+		}
+		slog.Info("listening on", "network", l.Network, "addr", ln.Addr().String(), "tls", l.Network != "unix" && tlsConfig != nil)
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// listenUnix creates the unix socket, replacing a stale one left by a previous run, and
+// sets its mode and owner.
+func listenUnix(l config.ListenerConfig) (net.Listener, error) {
+	mode, err := l.FileMode()
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(l.Address); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, errors.New("file exists and is not a socket")
+		}
+		os.Remove(l.Address)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Address), 0755); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", l.Address)
+	if err != nil {
+		return nil, err
+	}
+	uid, gid := -1, -1
+	if l.Owner != "" {
+		if uid, err = lookupUID(l.Owner); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	if l.Group != "" {
+		if gid, err = lookupGID(l.Group); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	if err := os.Chown(l.Address, uid, gid); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	if err := os.Chmod(l.Address, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// peerCredentialsIdentity returns the user that the peer credentials of a unix socket
+// client map to in auth.local_users. root is an admin unless mapped otherwise.
+func peerCredentialsIdentity(c *fiber.Ctx) (string, auth.Role, bool) {
+	conn, ok := c.Context().Conn().(*net.UnixConn)
+	if !ok {
+		return "", "", false
+	}
+	credUID, credGID, ok := peerCredentials(conn)
+	if !ok {
+		return "", "", false
+	}
+
+	uid := strconv.Itoa(int(credUID))
+	var groups []string
+	if u, err := user.LookupId(uid); err == nil {
+		groups, _ = u.GroupIds()
+	}
+	groups = append(groups, strconv.Itoa(int(credGID)))
+	for _, m := range conf().Auth.LocalUsers {
+		if m.UnixUser != "" {
+			if id, err := lookupUID(m.UnixUser); err != nil || strconv.Itoa(id) != uid {
+				continue
+			}
+		} else if id, err := lookupGID(m.UnixGroup); err != nil || !slices.Contains(groups, strconv.Itoa(id)) {
+			continue
+		}
+		if m.Role != "" {
+			return m.User, auth.Role(m.Role), true
+		}
+		u, err := lookupUser(m.User)
+		if err != nil {
+			securityLog.Warn("local user maps to a user that does not exist", "uid", uid, "user", m.User)
+			return "", "", false
+		}
+		return u.Name, u.Role, true
+	}
+	if credUID == 0 {
+		return "root", auth.RoleAdmin, true
+	}
+	return "", "", false
+}
+
+// lookupUID returns the uid of a user name or numeric uid.
+func lookupUID(name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+// lookupGID returns the gid of a group name or numeric gid.
+func lookupGID(name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
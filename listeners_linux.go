@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// peerCredentials returns the uid and gid of the process on the other end of a unix socket.
+func peerCredentials(conn *net.UnixConn) (uid, gid uint32, ok bool) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, 0, false
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		securityLog.Warn("get peer credentials", "error", errors.Join(err, credErr))
+		return 0, 0, false
+	}
+	return cred.Uid, cred.Gid, true
+}
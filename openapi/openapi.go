@@ -0,0 +1,8 @@
+// Package openapi embeds the OpenAPI specification of the REST api, served at
+// /api/v1/openapi.json. the client package is generated from it.
+package openapi
+
+import _ "embed"
+
+//go:embed openapi.json
+var Spec []byte
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tiredkangaroo/system/config"
+	"github.com/tiredkangaroo/system/openapi"
+)
+
+type openAPIOperation struct {
+	Parameters []struct {
+		Ref  string `json:"$ref"`
+		Name string `json:"name"`
+		In   string `json:"in"`
+	} `json:"parameters"`
+	Responses map[string]any `json:"responses"`
+}
+
+// specOperations returns the operations of the specification by "METHOD /path".
+func specOperations(t *testing.T) map[string]openAPIOperation {
+	t.Helper()
+	var spec struct {
+		Paths map[string]map[string]openAPIOperation `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec, &spec); err != nil {
+		t.Fatalf("parse openapi.json: %v", err)
+	}
+	ops := make(map[string]openAPIOperation)
+	for path, methods := range spec.Paths {
+		for method, op := range methods {
+			ops[strings.ToUpper(method)+" "+path] = op
+		}
+	}
+	return ops
+}
+
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+// routeOperations returns the api routes of the server by "METHOD /path", with the path
+// written like in the specification.
+func routeOperations(t *testing.T) map[string]bool {
+	t.Helper()
+	currentConfig.Store(config.Default())
+	app := newApp(nil, nil, nil)
+	routes := make(map[string]bool)
+	for _, r := range app.GetRoutes(true) {
+		path, ok := strings.CutPrefix(r.Path, "/api/v1")
+		// fiber adds HEAD to GET routes, and All routes every method
+		if !ok || !slices.Contains([]string{"GET", "POST", "PUT", "PATCH", "DELETE"}, r.Method) {
+			continue
+		}
+		path = routeParam.ReplaceAllString(path, "{$1}")
+		path = strings.Replace(path, "*", "{path}", 1)
+		routes[r.Method+" "+path] = true
+	}
+	return routes
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	ops := specOperations(t)
+	routes := routeOperations(t)
+	for route := range routes {
+		if _, ok := ops[route]; !ok {
+			t.Errorf("route %s is not in openapi/openapi.json", route)
+		}
+	}
+	for op := range ops {
+		if !routes[op] {
+			t.Errorf("%s is in openapi/openapi.json but not a route", op)
+		}
+	}
+}
+
+func TestOpenAPIPathParameters(t *testing.T) {
+	pathParam := regexp.MustCompile(`\{(\w+)\}`)
+	for key, op := range specOperations(t) {
+		var want []string
+		for _, m := range pathParam.FindAllStringSubmatch(key, -1) {
+			want = append(want, m[1])
+		}
+		var got []string
+		for _, p := range op.Parameters {
+			if p.Ref != "" {
+				t.Errorf("%s: use inline parameters instead of %s", key, p.Ref)
+			}
+			if p.In == "path" {
+				got = append(got, p.Name)
+			}
+		}
+		slices.Sort(want)
+		slices.Sort(got)
+		if !slices.Equal(want, got) {
+			t.Errorf("%s: path parameters are %v, want %v", key, got, want)
+		}
+		if len(op.Responses) == 0 {
+			t.Errorf("%s: no responses", key)
+		}
+	}
+}
+
+func TestOpenAPIReferences(t *testing.T) {
+	var spec map[string]any
+	if err := json.Unmarshal(openapi.Spec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				var target any = spec
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]any)
+					target = m[part]
+				}
+				if target == nil {
+					t.Errorf("unresolved reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	currentConfig.Store(config.Default())
+	setCORSConfig(conf().CORS)
+	app := newApp(nil, nil, nil)
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || string(body) != string(openapi.Spec) {
+		t.Fatalf("GET /api/v1/openapi.json = %d, want the specification", resp.StatusCode)
+	}
+}
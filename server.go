@@ -1,63 +1,216 @@
 package main
 
 import (
-	"crypto/tls"
 	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"log/slog"
-	"net"
 	"os"
+	"os/signal"
 	"runtime"
 	"slices"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/etag"
+	"github.com/tiredkangaroo/system/audit"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/cli"
+	"github.com/tiredkangaroo/system/config"
+	"github.com/tiredkangaroo/system/fake"
 	"github.com/tiredkangaroo/system/linux"
+	"github.com/tiredkangaroo/system/openapi"
 	"github.com/tiredkangaroo/system/system"
 )
 
-var DEBUG = os.Getenv("DEBUG") == "true"
-
-var certFile = os.Getenv("TLS_CERT_FILE")
-var keyFile = os.Getenv("TLS_KEY_FILE")
+var demo = flag.Bool("demo", false, "run on a fake system with made-up metrics, processes, services and logs, e.g. to try the web interface")
 
 func main() {
-	slog.SetLogLoggerLevel(slog.LevelInfo)
-	authInit()
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+	flag.Parse()
+	if err := configInit(); err != nil {
+		slog.Error("load config", "error", err)
+		return
+	}
+	if err := authInit(); err != nil {
+		slog.Error("auth init", "error", err)
+		return
+	}
+	auditInit()
+	if err := hubInit(); err != nil {
+		slog.Error("hub init", "error", err)
+		return
+	}
 	var sys system.System
-	switch runtime.GOOS {
-	case "linux", "darwin":
+	switch {
+	case *demo:
+		slog.Warn("demo mode, nothing the server shows or does is real")
+		demoSys := fake.NewDemo()
+		go demoSys.RunDemo(shuttingDown)
+		sys = demoSys
+	case runtime.GOOS == "linux" || runtime.GOOS == "darwin":
 		sys = &linux.LinuxSystem{}
 	default:
 		panic("Unsupported OS")
 	}
 
-	app := fiber.New()
+	setCORSConfig(conf().CORS)
+	infoService := system.NewSystemInfoService(sys, infoIntervals(conf().Refresh))
+	go infoService.Run(shuttingDown)
+	powerScheduler := system.NewPowerScheduler(sys)
+	app := newApp(sys, infoService, powerScheduler)
+	agentInit(app, infoService)
 
-	api := app.Group("/api/v1", cors.New(cors.Config{
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowMethods:     "GET, POST, PATCH, DELETE, OPTIONS",
-		AllowCredentials: true,
-		ExposeHeaders:    "Content-Length, Content-Type",
-		MaxAge:           3600,
-		AllowOriginsFunc: func(origin string) bool {
-			return true
-		},
-	}), requireAuthMiddleware)
+	reloadConfigOnSIGHUP(func(c *config.Config) {
+		setCORSConfig(c.CORS)
+		infoService.SetIntervals(infoIntervals(c.Refresh))
+		if fleet != nil {
+			fleet.SetOptions(hubOptions(c.Hub))
+		}
+	})
 
-	infoService := system.NewSystemInfoService(sys, time.Second*5)
+	tlsConfig, err := tlsInit()
+	if err != nil {
+		slog.Error("tls", "error", err)
+		return
+	}
+	if tlsConfig == nil {
+		slog.Warn("TLS disabled, set tls.self_signed = true (or SYSTEM_TLS_SELF_SIGNED=true) to generate a certificate, or set tls.cert_file and tls.key_file (or TLS_CERT_FILE and TLS_KEY_FILE) with valid x509 certs to enable")
+	}
+
+	listeners, err := listen(tlsConfig)
+	if err != nil {
+		slog.Error("create listener", "error", err)
+		return
+	}
+	grpcSrv, grpcListener, err := grpcInit(sys, infoService, powerScheduler, tlsConfig)
+	if err != nil {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+		slog.Error("create listener", "error", err)
+		return
+	}
+	errs := make(chan error, len(listeners)+1)
+	for _, ln := range listeners {
+		go func() {
+			errs <- app.Listener(ln)
+		}()
+	}
+	if grpcSrv != nil {
+		go func() {
+			errs <- grpcSrv.Serve(grpcListener)
+		}()
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	sdNotify("READY=1")
+	go sdWatchdog()
+
+	select {
+	case err := <-errs:
+		slog.Error("server", "error", err)
+	case sig := <-stop:
+		slog.Info("shutting down", "signal", sig.String())
+	}
+	shutdown(app, grpcSrv)
+}
+
+func infoIntervals(c config.RefreshConfig) system.Intervals {
+	return system.Intervals{
+		Metrics:   c.MetricsInterval.D(),
+		Processes: c.ProcessesInterval.D(),
+		Services:  c.ServicesInterval.D(),
+	}
+}
+
+// newApp registers every route of the server.
+func newApp(sys system.System, infoService *system.SystemInfoService, powerScheduler *system.PowerScheduler) *fiber.App {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true, // every listener is logged instead
+	})
+
+	api := app.Group("/api/v1", corsMiddleware, csrfMiddleware)
+	// login and the api specification are registered before requireAuthMiddleware so that
+	// they can be reached without being authenticated. every route registered after the
+	// middleware requires authentication.
+	api.Post("/auth/login", loginHandler)
+	api.Get("/openapi.json", etag.New(), func(c *fiber.Ctx) error {
+		c.Type("json")
+		return c.Send(openapi.Spec)
+	})
+	registerAgentConnectRoute(api)
+	api.Use(requireAuthMiddleware)
 
 	api.Get("/auth", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
 			"ok":            true,
-			"requires_auth": totpSecret != "" && jwtSecret != "",
+			"requires_auth": authEnabled(),
+			"user":          identity(c),
+			"role":          role(c),
+			"permissions":   permissions(c),
 		})
 	})
-	api.Get("/info", func(c *fiber.Ctx) error {
-		info, err := infoService.GetSystemInfo()
+	api.Post("/auth/logout", logoutHandler)
+	api.Get("/sessions", func(c *fiber.Ctx) error {
+		owner := identity(c)
+		if can(c, auth.PermAdmin) {
+			owner = ""
+		}
+		return c.JSON(sessions.List(owner))
+	})
+	api.Delete("/sessions/:id", auditMiddleware, func(c *fiber.Ctx) error {
+		session, err := sessions.Get(c.Params("id"))
+		if err == nil && session.User != identity(c) && !can(c, auth.PermAdmin) {
+			err = auth.ErrSessionNotFound
+		}
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		}
+		err = sessions.Revoke(session.ID)
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	})
+	// revokes every session (or every session of a user), e.g: after rotating secrets
+	api.Post("/sessions/revoke_all", auditMiddleware, requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		var req struct {
+			User string `json:"user"` // if empty, sessions of all users are revoked
+		}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return sendErrorMap(c, fiber.StatusBadRequest, err)
+			}
+		}
+		n, err := sessions.RevokeAll(req.User)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		securityLog.Warn("revoked sessions", "event", "sessions_revoked", "by", identity(c), "user", req.User, "count", n)
+		return c.JSON(fiber.Map{"revoked": n})
+	})
+	// only the fields listed in ?fields=cpu_usage,memory_used,services are collected and
+	// returned, or the whole system info without it
+	api.Get("/info", requirePermission(auth.PermReadMetrics), func(c *fiber.Ctx) error {
+		fields, err := system.ParseFields(c.Query("fields"))
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		info, err := infoService.GetSystemInfo(c.Context(), fields)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		if c.Query("fields") != "" {
+			return c.JSON(info.Select(fields))
+		}
+		return c.JSON(info)
+	})
+	api.Get("/info/static", requirePermission(auth.PermReadMetrics), etag.New(), func(c *fiber.Ctx) error {
+		info, err := infoService.GetStaticInfo(c.Context())
 		if err != nil {
 			return sendErrorMap(c, fiber.StatusInternalServerError, err)
 		}
@@ -65,55 +218,126 @@ func main() {
 	})
 	api.Get("/is_privileged", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
-			"privileged": os.Geteuid() == 0,
+			"privileged": privileged(),
 		})
 	})
-	api.Get("/info/ws", websocket.New(func(c *websocket.Conn) {
-		info, err := infoService.GetSystemInfo()
-		if err != nil {
-			slog.Error("websocket get system info", "error", err)
-			return
-		}
-		err = c.WriteJSON(info)
-		if err != nil {
-			slog.Error("websocket write json", "error", err)
-			return
-		}
-		ticker := time.NewTicker(system.InfoRefreshInterval)
-		defer ticker.Stop()
+	// sends the whole system info when metrics are collected, at most every websocket interval
+	api.Get("/info/ws", requirePermission(auth.PermReadMetrics), websocket.New(func(c *websocket.Conn) {
 		defer c.Close()
-		for range ticker.C {
-			info, err := infoService.GetSystemInfo()
+		defer infoService.Watch(system.AllFields)()
+		for {
+			_, next, _ := infoService.GetMetrics(shuttingDown, system.MetricsFields)
+			if shuttingDown.Err() != nil {
+				break
+			}
+			info, err := infoService.GetSystemInfo(shuttingDown, system.AllFields)
 			if err != nil {
 				slog.Error("websocket get system info", "error", err)
-				break
+				return
 			}
 			err = c.WriteJSON(info)
 			if err != nil {
 				slog.Error("websocket write json", "error", err)
+				return
+			}
+			if !waitForUpdate(shuttingDown, next, conf().Refresh.WebsocketInterval.D()) {
 				break
 			}
 		}
+		c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"), time.Now().Add(time.Second))
 	}))
-	api.Get("/system/logs", func(c *fiber.Ctx) error {
+	api.Get("/ws", requirePermission(auth.PermReadMetrics), topicsPermissions(true), websocket.New(topicsWebsocket(func(*websocket.Conn) topicSource {
+		return localSource{sys: sys, infoService: infoService}
+	})))
+	api.Get("/system/logs", requirePermission(auth.PermReadLogs), requireFeature("logs"), func(c *fiber.Ctx) error {
 		logOptions := getLogOptionsFromCtx(c)
 		reader, err := sys.GetSystemLogs(logOptions)
 		return sendReader(c, reader, err)
 	})
-	api.Post("/system/shutdown", privilegeMiddleware, func(c *fiber.Ctx) error {
-		if err := sys.Shutdown(); err != nil {
+	api.Post("/system/shutdown", auditMiddleware, requirePermission(auth.PermPowerActions), requireFeature("power_actions"), privilegeMiddleware, auditAttempt, func(c *fiber.Ctx) error {
+		err := sys.Shutdown()
+		return sendErrorMap(c, fiber.StatusInternalServerError, err) // may never reach here
+	})
+	api.Post("/system/reboot", auditMiddleware, requirePermission(auth.PermPowerActions), requireFeature("power_actions"), privilegeMiddleware, auditAttempt, func(c *fiber.Ctx) error {
+		err := sys.Reboot()
+		return sendErrorMap(c, fiber.StatusInternalServerError, err) // may never reach here
+	})
+	api.Post("/system/power/schedule", auditMiddleware, requirePermission(auth.PermPowerActions), requireFeature("power_actions"), privilegeMiddleware, func(c *fiber.Ctx) error {
+		var req struct {
+			Action  system.PowerAction `json:"action"`
+			At      int64              `json:"at"`    // unix timestamp
+			Delay   int64              `json:"delay"` // seconds from now, used if at is not set
+			Message string             `json:"message"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		var at time.Time
+		switch {
+		case req.At > 0:
+			at = time.Unix(req.At, 0)
+		case req.Delay > 0:
+			at = time.Now().Add(time.Duration(req.Delay) * time.Second)
+		default:
+			return sendErrorMap(c, fiber.StatusBadRequest, errors.New("one of at or delay must be provided"))
+		}
+		info, err := infoService.GetStaticInfo(c.Context())
+		if err != nil {
 			return sendErrorMap(c, fiber.StatusInternalServerError, err)
 		}
-		return c.SendStatus(fiber.StatusOK) // may never reach here
+		if err := req.Action.CheckSupported(info.PowerActions); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		scheduled, err := powerScheduler.Schedule(req.Action, at, req.Message)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		return c.JSON(scheduled)
+	})
+	api.Get("/system/power/scheduled", requirePermission(auth.PermReadMetrics), func(c *fiber.Ctx) error {
+		return c.JSON(powerScheduler.Pending())
 	})
-	api.Post("/system/reboot", privilegeMiddleware, func(c *fiber.Ctx) error {
-		if err := sys.Reboot(); err != nil {
+	api.Delete("/system/power/scheduled/:id", auditMiddleware, requirePermission(auth.PermPowerActions), requireFeature("power_actions"), privilegeMiddleware, func(c *fiber.Ctx) error {
+		err := powerScheduler.Cancel(c.Params("id"))
+		return sendErrorMap(c, fiber.StatusNotFound, err)
+	})
+	api.Post("/system/power/:action", auditMiddleware, requirePermission(auth.PermPowerActions), requireFeature("power_actions"), privilegeMiddleware, auditAttempt, func(c *fiber.Ctx) error {
+		action := system.PowerAction(c.Params("action"))
+		info, err := infoService.GetStaticInfo(c.Context())
+		if err != nil {
 			return sendErrorMap(c, fiber.StatusInternalServerError, err)
 		}
-		return c.SendStatus(fiber.StatusOK) // may never reach here
+		if err := action.CheckSupported(info.PowerActions); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		err = action.Run(sys)
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
 	})
-	api.Get("/process/:pid", func(c *fiber.Ctx) error {
-		info, err := infoService.GetSystemInfo()
+	api.Get("/audit", requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		if auditLogger == nil {
+			return sendErrorMap(c, fiber.StatusNotFound, errors.New("audit log is disabled"))
+		}
+		filter := audit.Filter{
+			Identity: c.Query("identity"),
+			Route:    c.Query("route"),
+			Limit:    c.QueryInt("limit", 100),
+		}
+		if since := c.QueryInt("since", -1); since != -1 {
+			t := time.Unix(int64(since), 0)
+			filter.Since = &t
+		}
+		if until := c.QueryInt("until", -1); until != -1 {
+			t := time.Unix(int64(until), 0)
+			filter.Until = &t
+		}
+		entries, err := auditLogger.Query(filter)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		return c.JSON(entries)
+	})
+	api.Get("/process/:pid", requirePermission(auth.PermReadMetrics), func(c *fiber.Ctx) error {
+		processes, _, err := infoService.GetProcesses(c.Context())
 		if err != nil {
 			return sendErrorMap(c, fiber.StatusInternalServerError, err)
 		}
@@ -121,112 +345,228 @@ func main() {
 		if err != nil {
 			return sendErrorMap(c, fiber.StatusBadRequest, errors.New("invalid PID"))
 		}
-		process := slices.IndexFunc(info.DynamicInfo.Processes, func(p system.Process) bool {
+		process := slices.IndexFunc(processes, func(p system.Process) bool {
 			return p.PID == int32(pid)
 		})
 		if process == -1 {
 			return sendErrorMap(c, fiber.StatusNotFound, errors.New("process not found"))
 		}
-		return c.JSON(info.DynamicInfo.Processes[process])
+		return c.JSON(processes[process])
 	})
-	api.Post("/process/:pid/signal/:signal", privilegeMiddleware, func(c *fiber.Ctx) error {
+	api.Post("/process/:pid/signal/:signal", auditMiddleware, requirePermission(auth.PermSignalProcesses), requireFeature("signals"), privilegeMiddleware, func(c *fiber.Ctx) error {
 		pid, err := c.ParamsInt("pid")
 		if err != nil {
 			return sendErrorMap(c, fiber.StatusBadRequest, errors.New("invalid PID"))
 		}
 		signal := c.Params("signal")
-		var syscallSignal syscall.Signal
-		switch signal {
-		case "SIGKILL":
-			syscallSignal = syscall.SIGKILL
-		case "SIGTERM":
-			syscallSignal = syscall.SIGTERM
-		case "SIGSTOP":
-			syscallSignal = syscall.SIGSTOP
-		case "SIGCONT":
-			syscallSignal = syscall.SIGCONT
-		case "SIGQUIT":
-			syscallSignal = syscall.SIGQUIT
-		default:
+		syscallSignal, ok := signals[signal]
+		if !ok {
 			return sendErrorMap(c, fiber.StatusBadRequest, errors.New("signal is invalid"))
 		}
-		err = syscall.Kill(pid, syscallSignal)
+		if !slices.Contains(conf().Policy.AllowedSignals, signal) {
+			return sendErrorMap(c, fiber.StatusForbidden, fmt.Errorf("sending %s is not allowed by the server configuration", signal))
+		}
+		err = sys.SignalProcess(int32(pid), syscallSignal)
 		return sendErrorMap(c, fiber.StatusInternalServerError, err)
 	})
-	api.Get("/service/:name", func(c *fiber.Ctx) error {
-		info, err := infoService.GetSystemInfo()
+	api.Get("/service/:name", requirePermission(auth.PermReadMetrics), func(c *fiber.Ctx) error {
+		services, _, err := infoService.GetServices(c.Context())
 		if err != nil {
-			return c.JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
 		}
 		name := c.Params("name")
-		service := slices.IndexFunc(info.DynamicInfo.Services, func(s system.Service) bool {
+		service := slices.IndexFunc(services, func(s system.Service) bool {
 			return s.Name == name
 		})
 		if service == -1 {
 			return sendErrorMap(c, fiber.StatusNotFound, errors.New("service not found"))
 		}
-		return c.JSON(info.DynamicInfo.Services[service])
+		return c.JSON(services[service])
 	})
-	api.Get("/service/:name/logs", func(c *fiber.Ctx) error {
+	api.Get("/service/:name/logs", requirePermission(auth.PermReadLogs), requireFeature("logs"), func(c *fiber.Ctx) error {
 		name := c.Params("name")
 		logOptions := getLogOptionsFromCtx(c)
 		reader, err := sys.GetServiceLog(name, logOptions)
 		return sendReader(c, reader, err)
 	})
-	api.Patch("/service/:name/start", privilegeMiddleware, func(c *fiber.Ctx) error {
+	api.Patch("/service/:name/start", auditMiddleware, requirePermission(auth.PermManageServices), requireFeature("services"), privilegeMiddleware, func(c *fiber.Ctx) error {
 		name := c.Params("name")
 		err := sys.StartService(name)
 		return sendErrorMap(c, fiber.StatusInternalServerError, err)
 	})
-	api.Patch("/service/:name/stop", privilegeMiddleware, func(c *fiber.Ctx) error {
+	api.Patch("/service/:name/stop", auditMiddleware, requirePermission(auth.PermManageServices), requireFeature("services"), protectedServiceMiddleware, privilegeMiddleware, func(c *fiber.Ctx) error {
 		name := c.Params("name")
 		err := sys.StopService(name)
 		return sendErrorMap(c, fiber.StatusInternalServerError, err)
 	})
-	api.Patch("/service/:name/restart", privilegeMiddleware, func(c *fiber.Ctx) error {
+	api.Patch("/service/:name/restart", auditMiddleware, requirePermission(auth.PermManageServices), requireFeature("services"), protectedServiceMiddleware, privilegeMiddleware, func(c *fiber.Ctx) error {
 		name := c.Params("name")
 		err := sys.RestartService(name)
 		return sendErrorMap(c, fiber.StatusInternalServerError, err)
 	})
 
-	// create listener with addr
-	addr := os.Getenv("LISTEN_ADDR")
-	if addr == "" {
-		addr = ":0"
-	}
-
-	listener, err := net.Listen("tcp4", addr)
-	if err != nil {
-		slog.Error("create listener", "error", err)
-		return
-	}
-	slog.Info("listening on", "addr", listener.Addr().String())
-	defer listener.Close()
-
-	if certFile != "" && keyFile != "" {
-		// if TLS cert and key provided, use them
-		// to get a tls.Certificate -> then wrap listener
-		// with a tls listener
-		var cert tls.Certificate
-		cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	api.Get("/users", requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		return c.JSON(users.List())
+	})
+	api.Post("/users", auditMiddleware, requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		var user auth.User
+		if err := c.BodyParser(&user); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		user.PendingTOTPSecret, user.PreviousTOTPSecret, user.PreviousTOTPSecretExpiresAt = "", "", nil
+		if err := users.Create(user); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		if user.TOTPSecret != "" {
+			return sendErrorMap(c, fiber.StatusOK, nil)
+		}
+		// without a secret, the user can only log in after completing enrollment
+		secret, err := users.StartEnrollment(user.Name)
 		if err != nil {
-			slog.Error("load x509 tls key pair", "error", err)
-			return
-		}
-		err = app.Listener(tls.NewListener(listener, &tls.Config{
-			Certificates: []tls.Certificate{cert},
-		}))
-	} else {
-		slog.Warn("TLS disabled, set TLS_CERT_FILE and TLS_KEY_FILE env vars with valid x509 certs to enable")
-		// no TLS, use plain listener
-		err = app.Listener(listener)
-	}
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		return sendEnrollment(c, user.Name, secret)
+	})
+	api.Post("/users/:name/enroll", auditMiddleware, sessionOnlyMiddleware, selfOrAdminMiddleware, func(c *fiber.Ctx) error {
+		name := c.Params("name")
+		if err := storeLegacyUser(name); err != nil {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		secret, err := users.StartEnrollment(name)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		}
+		return sendEnrollment(c, name, secret)
+	})
+	api.Get("/users/:name/enroll/qr.png", sessionOnlyMiddleware, selfOrAdminMiddleware, func(c *fiber.Ctx) error {
+		name := c.Params("name")
+		user, err := users.Get(name)
+		if err == nil && user.PendingTOTPSecret == "" {
+			err = auth.ErrNoPendingEnrollment
+		}
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		}
+		key, err := auth.TOTPKey(totpIssuer(), name, user.PendingTOTPSecret)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		qr, err := auth.QRCodePNG(key, 256)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		c.Type("png")
+		return c.Send(qr)
+	})
+	api.Post("/users/:name/enroll/verify", auditMiddleware, sessionOnlyMiddleware, selfOrAdminMiddleware, func(c *fiber.Ctx) error {
+		req := struct {
+			Code        string `json:"code"`
+			GracePeriod int64  `json:"grace_period"` // seconds the replaced secret keeps working
+		}{GracePeriod: 24 * 60 * 60}
+		if err := c.BodyParser(&req); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		err := users.CompleteEnrollment(c.Params("name"), req.Code, time.Duration(req.GracePeriod)*time.Second)
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		case errors.Is(err, auth.ErrInvalidCode), errors.Is(err, auth.ErrNoPendingEnrollment):
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		if err == nil {
+			securityLog.Info("TOTP secret activated", "event", "totp_enrolled", "user", c.Params("name"), "by", identity(c))
+		}
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	})
+	api.Patch("/users/:name", auditMiddleware, requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		var req struct {
+			Role auth.Role `json:"role"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		err := users.Update(c.Params("name"), func(u *auth.User) error {
+			u.Role = req.Role
+			return nil
+		})
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		} else if err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		// sessions carry the role in their claims, so they have to log in again
+		_, err = sessions.RevokeAll(c.Params("name"))
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	})
+	api.Delete("/users/:name", auditMiddleware, requirePermission(auth.PermAdmin), func(c *fiber.Ctx) error {
+		err := users.Delete(c.Params("name"))
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		}
+		// otherwise a user created later with the same name could use their tokens
+		if _, err := tokens.RevokeAll(c.Params("name")); err != nil {
+			return sendErrorMap(c, fiber.StatusInternalServerError, err)
+		}
+		_, err = sessions.RevokeAll(c.Params("name"))
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	})
+	api.Get("/tokens", func(c *fiber.Ctx) error {
+		owner := identity(c)
+		if can(c, auth.PermAdmin) {
+			owner = ""
+		}
+		return c.JSON(tokens.List(owner))
+	})
+	api.Post("/tokens", auditMiddleware, sessionOnlyMiddleware, func(c *fiber.Ctx) error {
+		if !authEnabled() {
+			return sendErrorMap(c, fiber.StatusBadRequest, errors.New("authentication is disabled"))
+		}
+		var req struct {
+			Name      string            `json:"name"`
+			Scopes    []auth.Permission `json:"scopes"`     // defaults to every permission of the user
+			ExpiresIn int64             `json:"expires_in"` // seconds, 0 means the token never expires
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		if req.Scopes == nil {
+			req.Scopes = role(c).Permissions()
+		}
+		for _, scope := range req.Scopes {
+			if !can(c, scope) {
+				return sendErrorMap(c, fiber.StatusForbidden, fmt.Errorf("cannot grant the %s scope without having the permission", scope))
+			}
+		}
+		var expiresAt *time.Time
+		if req.ExpiresIn > 0 {
+			t := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+			expiresAt = &t
+		}
+		plaintext, token, err := tokens.Create(identity(c), req.Name, req.Scopes, expiresAt)
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusBadRequest, err)
+		}
+		return c.JSON(fiber.Map{
+			"token":   plaintext, // the only time the token is returned
+			"details": token,
+		})
+	})
+	api.Delete("/tokens/:id", auditMiddleware, sessionOnlyMiddleware, func(c *fiber.Ctx) error {
+		token, err := tokens.Get(c.Params("id"))
+		if err == nil && token.User != identity(c) && !can(c, auth.PermAdmin) {
+			err = auth.ErrTokenNotFound
+		}
+		if err != nil {
+			return sendErrorMap(c, fiber.StatusNotFound, err)
+		}
+		err = tokens.Revoke(token.ID)
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
+	})
+	registerFleetRoutes(api)
 
-	if err != nil {
-		slog.Error("server", "error", err)
-	}
+	app.Get("/*", etag.New(), frontendHandler)
+	return app
 }
 
 func getLogOptionsFromCtx(c *fiber.Ctx) system.LogOptions {
@@ -240,18 +580,18 @@ func getLogOptionsFromCtx(c *fiber.Ctx) system.LogOptions {
 		logOptions.Until = &t
 	}
 	logOptions.ThisBootOnly = c.QueryBool("this_boot_only", false)
+	logOptions.Lines = c.QueryInt("lines", 0)
+	logOptions.Follow = c.QueryBool("follow", false)
 	return logOptions
 }
 
 func sendReader(c *fiber.Ctx, reader io.ReadCloser, err error) error {
 	if err != nil {
-		return c.JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return sendErrorMap(c, fiber.StatusInternalServerError, err)
 	}
 	c.Set("Content-Type", "text/plain; charset=utf-8")
 	c.Set("Transfer-Encoding", "chunked")
-	c.SendStream(reader, -1)
+	c.SendStream(trackStream(reader), -1)
 	return nil
 }
 func sendErrorMap(c *fiber.Ctx, errStatus int, err error) error {
@@ -265,9 +605,53 @@ func sendErrorMap(c *fiber.Ctx, errStatus int, err error) error {
 	})
 }
 
+// privileged reports whether the server may perform privileged actions. the fake system of
+// the demo mode doesn't need root.
+func privileged() bool {
+	return os.Geteuid() == 0 || *demo
+}
+
 func privilegeMiddleware(c *fiber.Ctx) error {
-	if os.Geteuid() == 0 {
+	if !conf().Policy.RequireRoot || privileged() {
 		return c.Next()
 	}
 	return sendErrorMap(c, fiber.StatusForbidden, errors.New("this action requires root privileges to perform (try running with sudo or as root)"))
 }
+
+// requireFeature rejects requests to a feature that is disabled in the configuration.
+func requireFeature(name string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !conf().Features.Enabled(name) {
+			return sendErrorMap(c, fiber.StatusForbidden, fmt.Errorf("%s is disabled by the server configuration", name))
+		}
+		return c.Next()
+	}
+}
+
+// signals are the signals that can be sent to processes, by name.
+var signals = map[string]syscall.Signal{
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGSTOP": syscall.SIGSTOP,
+	"SIGCONT": syscall.SIGCONT,
+	"SIGQUIT": syscall.SIGQUIT,
+}
+
+// protectedServiceMiddleware rejects requests to stop or restart a service listed in
+// policy.protected_services.
+func protectedServiceMiddleware(c *fiber.Ctx) error {
+	if isProtectedService(c.Params("name")) {
+		return sendErrorMap(c, fiber.StatusForbidden, fmt.Errorf("%s is protected by the server configuration", c.Params("name")))
+	}
+	return c.Next()
+}
+
+func isProtectedService(name string) bool {
+	name = strings.TrimSuffix(name, ".service")
+	for _, protected := range conf().Policy.ProtectedServices {
+		if strings.TrimSuffix(protected, ".service") == name {
+			return true
+		}
+	}
+	return false
+}
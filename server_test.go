@@ -0,0 +1,568 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/audit"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/config"
+	"github.com/tiredkangaroo/system/fake"
+	"github.com/tiredkangaroo/system/system"
+)
+
+// testSystem returns a fake system with a few processes, services and log entries, whose
+// metrics and processes stay the same until more are pushed.
+func testSystem() *fake.FakeSystem {
+	return &fake.FakeSystem{
+		Static: system.StaticInfo{
+			OS:              "linux",
+			Hostname:        "test",
+			CPU:             "Test CPU",
+			NumCPU:          4,
+			Arch:            "amd64",
+			Memory:          8 << 30,
+			StorageCapacity: 100 << 30,
+			PowerActions: []system.PowerAction{
+				system.PowerActionShutdown, system.PowerActionReboot, system.PowerActionSuspend,
+				system.PowerActionHibernate, system.PowerActionSuspendThenHibernate,
+			},
+		},
+		Metrics: []system.Metrics{{CPU_Usage: 12.5, MemoryUsed: 2 << 30, StorageUsed: 40 << 30, Uptime: 3600}},
+		Processes: [][]system.Process{{
+			{PID: 1, Name: "init", Status: "sleep", Threads: 1},
+			{PID: 42, Name: "worker", Status: "running", Threads: 4, CPUPercent: 50},
+		}},
+		Services: []system.Service{
+			{Name: "nginx.service", Status: "running", Description: "web server"},
+			{Name: "ssh.service", Status: "running"},
+			{Name: "backup.service", Status: "failed"},
+		},
+		Logs: []fake.LogEntry{
+			{Time: time.Unix(1000, 0), Message: "previous boot", Boot: 1},
+			{Time: time.Unix(2000, 0), Message: "booted"},
+			{Time: time.Unix(2100, 0), Service: "nginx.service", Message: "started"},
+			{Time: time.Unix(2200, 0), Service: "ssh.service", Message: "listening"},
+			{Time: time.Unix(2300, 0), Service: "nginx.service", Message: "GET /"},
+		},
+	}
+}
+
+type testServer struct {
+	t           *testing.T
+	app         *fiber.App
+	sys         *fake.FakeSystem
+	infoService *system.SystemInfoService
+	addr        string // once listening
+}
+
+// newTestServer returns a server on testSystem, with its collectors running and the default
+// configuration changed by configure. the tests don't run as root, so require_root is off.
+func newTestServer(t *testing.T, configure func(c *config.Config)) *testServer {
+	t.Helper()
+	c := config.Default()
+	c.StateDir = t.TempDir()
+	c.Policy.RequireRoot = false
+	c.Refresh = config.RefreshConfig{
+		MetricsInterval:   config.Duration(10 * time.Millisecond),
+		ProcessesInterval: config.Duration(10 * time.Millisecond),
+		ServicesInterval:  config.Duration(10 * time.Millisecond),
+		WebsocketInterval: config.Duration(10 * time.Millisecond),
+	}
+	if configure != nil {
+		configure(c)
+	}
+	currentConfig.Store(c)
+	setCORSConfig(c.CORS)
+	if err := authInit(); err != nil {
+		t.Fatal(err)
+	}
+	authLimiter = auth.NewLimiter(auth.DefaultLimiterOptions)
+	totpReplayGuard = auth.NewReplayGuard()
+	auditInit()
+	t.Cleanup(func() {
+		if auditLogger != nil {
+			auditLogger.Close()
+			auditLogger = nil
+		}
+	})
+
+	sys := testSystem()
+	infoService := system.NewSystemInfoService(sys, infoIntervals(c.Refresh))
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		infoService.Run(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	return &testServer{t: t, app: newApp(sys, infoService, system.NewPowerScheduler(sys)), sys: sys, infoService: infoService}
+}
+
+// request sends a request to the routes of the server, with body as JSON if it's not nil.
+// headers are pairs of names and values.
+func (s *testServer) request(method, path string, body any, headers ...string) *http.Response {
+	s.t.Helper()
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Add(headers[i], headers[i+1])
+	}
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp
+}
+
+// call sends a request like request, checks the status of the response and decodes it into
+// v if it's not nil.
+func (s *testServer) call(method, path string, body any, status int, v any, headers ...string) *http.Response {
+	s.t.Helper()
+	resp := s.request(method, path, body, headers...)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if resp.StatusCode != status {
+		s.t.Fatalf("%s %s = %d %s, want %d", method, path, resp.StatusCode, data, status)
+	}
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			s.t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+		}
+	}
+	return resp
+}
+
+// text sends a GET request and returns the body of the response, which must be 200 OK.
+func (s *testServer) text(path string, headers ...string) string {
+	s.t.Helper()
+	resp := s.request("GET", path, nil, headers...)
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		s.t.Fatalf("GET %s = %d %s, want 200", path, resp.StatusCode, data)
+	}
+	return string(data)
+}
+
+// listen serves the server on a local port, for websockets and streams, and returns its address.
+func (s *testServer) listen() string {
+	s.t.Helper()
+	if s.addr != "" {
+		return s.addr
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	go s.app.Listener(ln)
+	s.t.Cleanup(func() { s.app.ShutdownWithTimeout(time.Second) })
+	s.addr = ln.Addr().String()
+	return s.addr
+}
+
+// eventually fails the test if cond doesn't become true in a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type errorResponse struct {
+	Error *string `json:"error"`
+}
+
+func TestInfo(t *testing.T) {
+	s := newTestServer(t, nil)
+	var info system.SystemInfo
+	s.call("GET", "/api/v1/info", nil, 200, &info)
+	if info.Hostname != "test" || info.CPU_Usage != 12.5 || len(info.Processes) != 2 || len(info.Services) != 3 {
+		t.Fatalf("GET /api/v1/info = %+v", info)
+	}
+
+	var static system.StaticInfo
+	resp := s.call("GET", "/api/v1/info/static", nil, 200, &static)
+	if static.Hostname != "test" || static.NumCPU != 4 || len(static.PowerActions) != 5 {
+		t.Fatalf("GET /api/v1/info/static = %+v", static)
+	}
+	s.call("GET", "/api/v1/info/static", nil, 304, nil, "If-None-Match", resp.Header.Get("ETag"))
+
+	var privileged struct{ Privileged bool }
+	s.call("GET", "/api/v1/is_privileged", nil, 200, &privileged)
+	if privileged.Privileged != (os.Geteuid() == 0) {
+		t.Fatalf("privileged = %v as uid %d", privileged.Privileged, os.Geteuid())
+	}
+}
+
+func TestInfoFields(t *testing.T) {
+	s := newTestServer(t, nil)
+	var fields map[string]any
+	s.call("GET", "/api/v1/info?fields=cpu_usage,memory_used", nil, 200, &fields)
+	want := map[string]any{"cpu_usage": 12.5, "memory_used": float64(2 << 30)}
+	if fmt.Sprint(fields) != fmt.Sprint(want) {
+		t.Fatalf("selected fields = %v, want %v", fields, want)
+	}
+	if n := s.sys.Called("GetProcesses") + s.sys.Called("GetServices"); n != 0 {
+		t.Fatalf("processes and services were collected %d times without being requested", n)
+	}
+
+	fields = nil
+	s.call("GET", "/api/v1/info?fields=services", nil, 200, &fields)
+	if len(fields) != 1 || len(fields["services"].([]any)) != 3 {
+		t.Fatalf("selected fields = %v, want the services", fields)
+	}
+	s.call("GET", "/api/v1/info?fields=cpu_usage,load", nil, 400, nil)
+}
+
+func TestInfoErrors(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.sys.Fail("GetMetrics", errors.New("no sensors"))
+	var resp errorResponse
+	s.call("GET", "/api/v1/info?fields=cpu_temp", nil, 500, &resp)
+	if *resp.Error != "no sensors" {
+		t.Fatalf("error = %q", *resp.Error)
+	}
+	s.sys.Fail("GetProcesses", errors.New("no /proc"))
+	s.call("GET", "/api/v1/process/1", nil, 500, nil)
+	s.sys.Fail("GetServices", errors.New("no systemd"))
+	s.call("GET", "/api/v1/service/nginx.service", nil, 500, nil)
+}
+
+func TestProcesses(t *testing.T) {
+	s := newTestServer(t, nil)
+	var p system.Process
+	s.call("GET", "/api/v1/process/42", nil, 200, &p)
+	if p.Name != "worker" || p.CPUPercent != 50 {
+		t.Fatalf("GET /api/v1/process/42 = %+v", p)
+	}
+	s.call("GET", "/api/v1/process/7", nil, 404, nil)
+	s.call("GET", "/api/v1/process/worker", nil, 400, nil)
+}
+
+func TestSignalProcess(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.Policy.AllowedSignals = []string{"SIGSTOP", "SIGCONT", "SIGTERM"}
+	})
+	s.call("POST", "/api/v1/process/42/signal/SIGSTOP", nil, 200, nil)
+	if !slices.Contains(s.sys.Calls(), "SignalProcess 42 stopped (signal)") {
+		t.Fatalf("calls = %v, want SIGSTOP sent to 42", s.sys.Calls())
+	}
+	eventually(t, "the process to be stopped", func() bool {
+		var p system.Process
+		s.call("GET", "/api/v1/process/42", nil, 200, &p)
+		return p.Status == "stop"
+	})
+	s.call("POST", "/api/v1/process/42/signal/SIGTERM", nil, 200, nil)
+	eventually(t, "the process to be gone", func() bool {
+		return s.request("GET", "/api/v1/process/42", nil).StatusCode == 404
+	})
+	s.call("POST", "/api/v1/process/42/signal/SIGTERM", nil, 500, nil) // no such process
+
+	s.call("POST", "/api/v1/process/1/signal/SIGHUP", nil, 400, nil)
+	s.call("POST", "/api/v1/process/1/signal/SIGKILL", nil, 403, nil) // not allowed by the policy
+	s.call("POST", "/api/v1/process/init/signal/SIGTERM", nil, 400, nil)
+
+	conf().Features.Signals = false
+	s.call("POST", "/api/v1/process/1/signal/SIGTERM", nil, 403, nil)
+	if n := s.sys.Called("SignalProcess 1 terminated"); n != 0 {
+		t.Fatalf("signals were sent %d times while disabled", n)
+	}
+}
+
+func TestPrivilegedActionsRequireRoot(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("running as root")
+	}
+	s := newTestServer(t, func(c *config.Config) { c.Policy.RequireRoot = true })
+	s.call("POST", "/api/v1/process/42/signal/SIGTERM", nil, 403, nil)
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 403, nil)
+	s.call("POST", "/api/v1/system/reboot", nil, 403, nil)
+	if calls := s.sys.Calls(); slices.ContainsFunc(calls, func(c string) bool { return !strings.HasPrefix(c, "Get") }) {
+		t.Fatalf("calls = %v, want no privileged ones", calls)
+	}
+}
+
+func TestServices(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.Policy.ProtectedServices = []string{"ssh"}
+	})
+	var svc system.Service
+	s.call("GET", "/api/v1/service/nginx.service", nil, 200, &svc)
+	if svc.Status != "running" || svc.Description != "web server" {
+		t.Fatalf("GET /api/v1/service/nginx.service = %+v", svc)
+	}
+	s.call("GET", "/api/v1/service/mysql.service", nil, 404, nil)
+
+	s.call("PATCH", "/api/v1/service/nginx.service/stop", nil, 200, nil)
+	eventually(t, "nginx to be stopped", func() bool {
+		s.call("GET", "/api/v1/service/nginx.service", nil, 200, &svc)
+		return svc.Status == "dead"
+	})
+	s.call("PATCH", "/api/v1/service/nginx/start", nil, 200, nil)
+	s.call("PATCH", "/api/v1/service/backup.service/restart", nil, 200, nil)
+	s.call("PATCH", "/api/v1/service/mysql.service/start", nil, 500, nil)
+
+	// protected services can be started, but not stopped or restarted
+	s.call("PATCH", "/api/v1/service/ssh.service/stop", nil, 403, nil)
+	s.call("PATCH", "/api/v1/service/ssh/restart", nil, 403, nil)
+	s.call("PATCH", "/api/v1/service/ssh.service/start", nil, 200, nil)
+
+	s.sys.Fail("RestartService nginx.service", errors.New("job failed"))
+	var resp errorResponse
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 500, &resp)
+	if *resp.Error != "job failed" {
+		t.Fatalf("error = %q", *resp.Error)
+	}
+
+	conf().Features.Services = false
+	s.call("PATCH", "/api/v1/service/nginx.service/start", nil, 403, nil)
+
+	want := []string{
+		"StopService nginx.service", "StartService nginx", "RestartService backup.service",
+		"StartService mysql.service", "StartService ssh.service", "RestartService nginx.service",
+	}
+	var got []string
+	for _, c := range s.sys.Calls() {
+		if !strings.HasPrefix(c, "Get") {
+			got = append(got, c)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	s := newTestServer(t, nil)
+	lines := func(path string) []string {
+		t.Helper()
+		var messages []string
+		for line := range strings.Lines(s.text(path)) {
+			_, message, _ := strings.Cut(line, "test ")
+			messages = append(messages, strings.TrimSpace(message))
+		}
+		return messages
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/api/v1/system/logs", []string{"kernel: previous boot", "kernel: booted", "nginx: started", "ssh: listening", "nginx: GET /"}},
+		{"/api/v1/system/logs?lines=2", []string{"ssh: listening", "nginx: GET /"}},
+		{"/api/v1/system/logs?since=2100&until=2200", []string{"nginx: started", "ssh: listening"}},
+		{"/api/v1/system/logs?this_boot_only=true&lines=4", []string{"kernel: booted", "nginx: started", "ssh: listening", "nginx: GET /"}},
+		{"/api/v1/service/nginx.service/logs", []string{"nginx: started", "nginx: GET /"}},
+		{"/api/v1/service/nginx/logs?lines=1", []string{"nginx: GET /"}},
+		{"/api/v1/service/mysql/logs", nil},
+	}
+	for _, tt := range tests {
+		if got := lines(tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("GET %s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	s.sys.Fail("GetServiceLog", errors.New("no journal"))
+	s.call("GET", "/api/v1/service/nginx/logs", nil, 500, nil)
+	conf().Features.Logs = false
+	s.call("GET", "/api/v1/system/logs", nil, 403, nil)
+}
+
+func TestFollowLogs(t *testing.T) {
+	s := newTestServer(t, nil)
+	addr := s.listen()
+	resp, err := http.Get("http://" + addr + "/api/v1/service/nginx/logs?follow=true&lines=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(resp.Body)
+	readLine := func() string {
+		t.Helper()
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		return line
+	}
+	if line := readLine(); !strings.HasSuffix(line, "nginx: GET /\n") {
+		t.Fatalf("first line = %q", line)
+	}
+	s.sys.Log(fake.LogEntry{Time: time.Unix(2400, 0), Service: "ssh.service", Message: "not followed"})
+	s.sys.Log(fake.LogEntry{Time: time.Unix(2500, 0), Service: "nginx.service", Message: "GET /about"})
+	if line := readLine(); !strings.HasSuffix(line, "nginx: GET /about\n") {
+		t.Fatalf("followed line = %q", line)
+	}
+	resp.Body.Close()
+	// the next entry fails to be written to the closed connection, which closes the reader
+	eventually(t, "the log to be closed", func() bool {
+		s.sys.Log(fake.LogEntry{Time: time.Unix(2600, 0), Service: "nginx.service", Message: "GET /"})
+		return s.sys.Following() == 0
+	})
+}
+
+func TestPowerActions(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.call("POST", "/api/v1/system/shutdown", nil, 200, nil)
+	s.call("POST", "/api/v1/system/reboot", nil, 200, nil)
+	for _, action := range []string{"suspend", "hibernate", "suspend-then-hibernate"} {
+		s.call("POST", "/api/v1/system/power/"+action, nil, 200, nil)
+	}
+	s.call("POST", "/api/v1/system/power/explode", nil, 400, nil)
+	var resp errorResponse
+	s.call("POST", "/api/v1/system/power/hybrid-sleep", nil, 400, &resp) // not supported by the test system
+	if !strings.Contains(*resp.Error, "supported actions: [shutdown reboot suspend hibernate suspend-then-hibernate]") {
+		t.Fatalf("error = %q, want the supported actions", *resp.Error)
+	}
+	want := []string{"Shutdown", "Reboot", "Suspend", "Hibernate", "SuspendThenHibernate"}
+	if got := slices.DeleteFunc(s.sys.Calls(), func(c string) bool { return strings.HasPrefix(c, "Get") }); !slices.Equal(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+
+	s.sys.Fail("Suspend", errors.New("not supported"))
+	s.call("POST", "/api/v1/system/power/suspend", nil, 500, nil)
+	conf().Features.PowerActions = false
+	s.call("POST", "/api/v1/system/shutdown", nil, 403, nil)
+	s.call("POST", "/api/v1/system/power/reboot", nil, 403, nil)
+}
+
+func TestScheduledPowerActions(t *testing.T) {
+	s := newTestServer(t, nil)
+	var scheduled system.ScheduledPowerAction
+	s.call("POST", "/api/v1/system/power/schedule", map[string]any{"action": "reboot", "delay": 3600, "message": "rebooting soon"}, 200, &scheduled)
+	if scheduled.Action != system.PowerActionReboot || time.Until(scheduled.At) < 59*time.Minute {
+		t.Fatalf("scheduled = %+v", scheduled)
+	}
+	at := time.Now().Add(2 * time.Hour).Unix()
+	s.call("POST", "/api/v1/system/power/schedule", map[string]any{"action": "shutdown", "at": at}, 200, nil)
+	s.call("POST", "/api/v1/system/power/schedule", map[string]any{"action": "shutdown"}, 400, nil)
+	s.call("POST", "/api/v1/system/power/schedule", map[string]any{"action": "explode", "delay": 60}, 400, nil)
+	s.call("POST", "/api/v1/system/power/schedule", map[string]any{"action": "hybrid-sleep", "delay": 60}, 400, nil)
+
+	var pending []system.ScheduledPowerAction
+	s.call("GET", "/api/v1/system/power/scheduled", nil, 200, &pending)
+	if len(pending) != 2 {
+		t.Fatalf("pending = %+v, want 2 actions", pending)
+	}
+	s.call("DELETE", "/api/v1/system/power/scheduled/"+scheduled.ID, nil, 200, nil)
+	s.call("DELETE", "/api/v1/system/power/scheduled/"+scheduled.ID, nil, 404, nil)
+	s.call("GET", "/api/v1/system/power/scheduled", nil, 200, &pending)
+	if len(pending) != 1 || pending[0].Action != system.PowerActionShutdown {
+		t.Fatalf("pending = %+v, want the shutdown", pending)
+	}
+	if !slices.ContainsFunc(s.sys.Calls(), func(c string) bool {
+		return strings.HasPrefix(c, "WallMessage ") && strings.HasSuffix(c, ": rebooting soon")
+	}) {
+		t.Fatalf("calls = %v, want the message sent", s.sys.Calls())
+	}
+	if slices.Contains(s.sys.Calls(), "Reboot") {
+		t.Fatal("the cancelled reboot ran")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 200, nil)
+	s.call("POST", "/api/v1/process/7/signal/SIGTERM", nil, 500, nil)
+	var entries []audit.Entry
+	s.call("GET", "/api/v1/audit", nil, 200, &entries)
+	if len(entries) != 2 {
+		t.Fatalf("audit = %+v, want 2 entries", entries)
+	}
+	restart := entries[slices.IndexFunc(entries, func(e audit.Entry) bool { return e.Target == "nginx.service" })]
+	if restart.Route != "/api/v1/service/:name/restart" || restart.Status != 200 || restart.Identity != "anonymous" {
+		t.Fatalf("restart entry = %+v", restart)
+	}
+	s.call("GET", "/api/v1/audit?route=/api/v1/process/:pid/signal/:signal", nil, 200, &entries)
+	if len(entries) != 1 || entries[0].Status != 500 || entries[0].Error == "" {
+		t.Fatalf("signal entries = %+v", entries)
+	}
+
+	// power actions are recorded before they run too, since they may never return
+	s.call("POST", "/api/v1/system/power/reboot", nil, 200, nil)
+	conf().Features.PowerActions = false
+	s.call("POST", "/api/v1/system/power/reboot", nil, 403, nil) // denied, so never attempted
+	s.call("GET", "/api/v1/audit?route=/api/v1/system/power/:action", nil, 200, &entries)
+	if len(entries) != 3 {
+		t.Fatalf("power entries = %+v, want 3", entries)
+	}
+	slices.SortStableFunc(entries, func(a, b audit.Entry) int { return a.Time.Compare(b.Time) })
+	if !entries[0].Attempt || entries[0].Status != 0 || entries[0].Target != "reboot" || entries[1].Attempt || entries[1].Status != 200 || entries[2].Attempt || entries[2].Status != 403 {
+		t.Fatalf("power entries = %+v, want the attempt, its result and the denial", entries)
+	}
+
+	// the state directory is a file, so the audit log can't be opened
+	s = newTestServer(t, func(c *config.Config) {
+		c.StateDir = filepath.Join(t.TempDir(), "file")
+		os.WriteFile(c.StateDir, nil, 0600)
+		c.Auth.UsersFile = filepath.Join(t.TempDir(), "users.json")
+		c.Auth.TokensFile = filepath.Join(t.TempDir(), "tokens.json")
+		c.Auth.SessionsFile = filepath.Join(t.TempDir(), "sessions.json")
+	})
+	s.call("GET", "/api/v1/audit", nil, 404, nil)
+}
+
+func TestWebInterface(t *testing.T) {
+	s := newTestServer(t, nil)
+	resp := s.request("GET", "/processes", nil)
+	if resp.StatusCode != 200 && resp.StatusCode != 404 { // 404 if it wasn't built
+		t.Fatalf("GET /processes = %d", resp.StatusCode)
+	}
+	s.call("GET", "/api/v1/nope", nil, 404, nil)
+	conf().Features.WebUI = false
+	s.call("GET", "/", nil, 404, nil)
+}
+
+func TestCrossOriginRequests(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.CORS.AllowOrigins = []string{"https://dashboard.example"}
+	})
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 403, nil, "Origin", "https://evil.example")
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 403, nil, "Referer", "https://evil.example/page")
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 200, nil, "Origin", "https://dashboard.example")
+	s.call("PATCH", "/api/v1/service/nginx.service/restart", nil, 200, nil, "Origin", "http://example.com") // the server's own
+	if n := s.sys.Called("RestartService nginx.service"); n != 2 {
+		t.Fatalf("nginx was restarted %d times, want 2", n)
+	}
+
+	resp := s.call("GET", "/api/v1/info/static", nil, 200, nil, "Origin", "https://dashboard.example")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://dashboard.example" {
+		t.Fatalf("Access-Control-Allow-Origin = %q", got)
+	}
+	resp = s.call("GET", "/api/v1/info/static", nil, 200, nil, "Origin", "https://evil.example")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("Access-Control-Allow-Origin = %q for another origin", got)
+	}
+}
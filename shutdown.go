@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+)
+
+// shuttingDown is cancelled when the server starts shutting down. long-lived handlers like
+// websockets watch it to say goodbye to their clients.
+var shuttingDown, beginShutdown = context.WithCancel(context.Background())
+
+// streams are the log streams being sent, closed on shutdown so that their journalctl
+// processes are killed instead of keeping the requests open.
+var streams = struct {
+	sync.Mutex
+	m map[*trackedStream]struct{}
+}{m: make(map[*trackedStream]struct{})}
+
+type trackedStream struct {
+	io.ReadCloser
+	once    sync.Once
+	err     error
+	stopped atomic.Bool
+}
+
+// Read ends the stream cleanly with io.EOF once it has been stopped, instead of with the
+// error of reading from the closed pipe.
+func (s *trackedStream) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if err != nil && s.stopped.Load() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *trackedStream) Close() error {
+	s.once.Do(func() {
+		streams.Lock()
+		delete(streams.m, s)
+		streams.Unlock()
+		s.err = s.ReadCloser.Close()
+	})
+	return s.err
+}
+
+// trackStream registers r to be closed on shutdown.
+func trackStream(r io.ReadCloser) io.ReadCloser {
+	s := &trackedStream{ReadCloser: r}
+	streams.Lock()
+	streams.m[s] = struct{}{}
+	streams.Unlock()
+	return s
+}
+
+// shutdown stops accepting connections, closes websockets and log streams, waits up to
+// shutdown_timeout for in-flight requests (and gRPC calls, if grpcSrv is not nil) and
+// flushes the audit log.
+func shutdown(app *fiber.App, grpcSrv *grpc.Server) {
+	sdNotify("STOPPING=1")
+	beginShutdown()
+
+	streams.Lock()
+	open := make([]*trackedStream, 0, len(streams.m))
+	for s := range streams.m {
+		open = append(open, s)
+	}
+	streams.Unlock()
+	for _, s := range open {
+		s.stopped.Store(true)
+		s.Close()
+	}
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		if grpcSrv != nil {
+			stopGRPC(grpcSrv, conf().ShutdownTimeout.D())
+		}
+		close(grpcStopped)
+	}()
+	if err := app.ShutdownWithTimeout(conf().ShutdownTimeout.D()); err != nil {
+		slog.Warn("in-flight requests did not finish in time", "timeout", conf().ShutdownTimeout.D().String(), "error", err)
+	}
+	<-grpcStopped
+	if auditLogger != nil {
+		if err := auditLogger.Close(); err != nil {
+			slog.Error("close audit log", "error", err)
+		}
+	}
+	slog.Info("shutdown complete")
+}
+
+// notifySocket is taken out of the environment so that child processes (systemctl,
+// journalctl) don't send notifications on behalf of the server.
+var notifySocket = func() string {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	os.Unsetenv("NOTIFY_SOCKET")
+	return socket
+}()
+
+// sdNotify sends a state like "READY=1" to systemd when running as a Type=notify service.
+func sdNotify(state string) {
+	socket := notifySocket
+	if socket == "" {
+		return
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:] // abstract namespace
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		slog.Warn("sd_notify", "state", state, "error", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		slog.Warn("sd_notify", "state", state, "error", err)
+	}
+}
+
+// sdWatchdog pings the systemd watchdog (WatchdogSec= in the unit) at half the interval it
+// expects until shutdown begins.
+func sdWatchdog() {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return
+	}
+	interval := time.Duration(usec) * time.Microsecond / 2
+	slog.Info("systemd watchdog enabled", "interval", interval.String())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shuttingDown.Done():
+			return
+		case <-ticker.C:
+			sdNotify("WATCHDOG=1")
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package system
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is a value published by a collector. It is shared by every reader and must
+// not be modified.
+type Snapshot[T any] struct {
+	Value  T
+	Fields Fields // the fields of Value that were collected, if it has any
+	Err    error
+	At     time.Time // when it was collected
+}
+
+// Broadcast holds the latest snapshot of a collector. The zero value is ready to use.
+type Broadcast[T any] struct {
+	mu      sync.Mutex
+	latest  *Snapshot[T]
+	changed chan struct{} // closed when a newer snapshot is published
+}
+
+func (b *Broadcast[T]) Publish(v T, fields Fields, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latest = &Snapshot[T]{Value: v, Fields: fields, Err: err, At: time.Now()}
+	if b.changed != nil {
+		close(b.changed)
+	}
+	b.changed = make(chan struct{})
+}
+
+// Wait returns the latest snapshot, waiting for the first one to be published, and a
+// channel closed when a newer one is.
+func (b *Broadcast[T]) Wait(ctx context.Context) (*Snapshot[T], <-chan struct{}, error) {
+	return b.WaitFor(ctx, 0, time.Time{})
+}
+
+// WaitFor returns the latest snapshot with at least fields that was collected after since,
+// waiting for it to be published, and a channel closed when a newer one is.
+func (b *Broadcast[T]) WaitFor(ctx context.Context, fields Fields, since time.Time) (*Snapshot[T], <-chan struct{}, error) {
+	for {
+		b.mu.Lock()
+		if b.changed == nil {
+			b.changed = make(chan struct{})
+		}
+		latest, changed := b.latest, b.changed
+		b.mu.Unlock()
+		if latest != nil && latest.Fields.Has(fields) && !latest.At.Before(since) {
+			return latest, changed, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Intervals are how often each kind of system info is collected.
+type Intervals struct {
+	Metrics   time.Duration
+	Processes time.Duration
+	Services  time.Duration
+}
+
+// demandTTL is how long fields keep being collected after they were last requested.
+const demandTTL = time.Minute
+
+// SystemInfoService collects system info in the background, with one goroutine per kind
+// of info, and shares the snapshots with every reader so that concurrent clients never
+// collect it themselves. Only the fields that were requested in the last minute, or are
+// watched, are collected, so an idle server doesn't scan /proc.
+type SystemInfoService struct {
+	sys       System
+	intervals atomic.Pointer[Intervals]
+
+	Static    Broadcast[StaticInfo] // collected once
+	Metrics   Broadcast[Metrics]
+	Processes Broadcast[[]Process]
+	Services  Broadcast[[]Service]
+
+	mu        sync.Mutex
+	requested map[Fields]time.Time // by field, when it was last requested
+	watchers  map[Fields]int       // by field
+	wake      map[Fields]chan struct{}
+}
+
+// Run collects system info until ctx is done.
+func (s *SystemInfoService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		// static info is retried until it's collected once
+		for {
+			info, err := s.sys.GetStaticInfo()
+			s.Static.Publish(info, 0, err)
+			if err == nil {
+				return
+			}
+			slog.Error("cannot get static system info", "error", err)
+			if !sleep(ctx, s.intervals.Load().Metrics, nil) {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		collect(ctx, s, "metrics", &s.Metrics, MetricsFields, s.sys.GetMetrics, func(i *Intervals) time.Duration { return i.Metrics })
+	}()
+	go func() {
+		defer wg.Done()
+		collect(ctx, s, "processes", &s.Processes, FieldProcesses, func(Fields) ([]Process, error) {
+			return s.sys.GetProcesses()
+		}, func(i *Intervals) time.Duration { return i.Processes })
+	}()
+	go func() {
+		defer wg.Done()
+		collect(ctx, s, "services", &s.Services, FieldServices, func(Fields) ([]Service, error) {
+			return s.sys.GetServices()
+		}, func(i *Intervals) time.Duration { return i.Services })
+	}()
+	wg.Wait()
+}
+
+// collect publishes the active fields of kind every interval, or when they become active.
+func collect[T any](ctx context.Context, s *SystemInfoService, name string, b *Broadcast[T], kind Fields, get func(Fields) (T, error), interval func(*Intervals) time.Duration) {
+	for {
+		if fields := s.active() & kind; fields != 0 {
+			v, err := get(fields)
+			if err != nil {
+				slog.Error("cannot get system info", "kind", name, "error", err)
+			}
+			b.Publish(v, fields, err)
+		}
+		if !sleep(ctx, interval(s.intervals.Load()), s.wake[kind]) {
+			return
+		}
+	}
+}
+
+// active returns the fields that were requested in the last demandTTL or are watched.
+func (s *SystemInfoService) active() Fields {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var fields Fields
+	for _, n := range fieldNames {
+		if s.watchers[n.field] > 0 || time.Since(s.requested[n.field]) < demandTTL {
+			fields |= n.field
+		}
+	}
+	return fields
+}
+
+// request records that fields are wanted, wakes up the collectors of those that weren't
+// being collected and returns them.
+func (s *SystemInfoService) request(fields Fields) (idle Fields) {
+	idle = fields &^ s.active()
+	s.mu.Lock()
+	for _, n := range fieldNames {
+		if fields.Has(n.field) {
+			s.requested[n.field] = time.Now()
+		}
+	}
+	s.mu.Unlock()
+	s.wakeUp(idle)
+	return idle
+}
+
+func (s *SystemInfoService) wakeUp(fields Fields) {
+	for kind, wake := range s.wake {
+		if fields&kind != 0 {
+			select {
+			case wake <- struct{}{}:
+			default: // already woken up
+			}
+		}
+	}
+}
+
+// Watch keeps fields collected until stop is called, e.g. while a client is subscribed to them.
+func (s *SystemInfoService) Watch(fields Fields) (stop func()) {
+	idle := fields &^ s.active()
+	s.mu.Lock()
+	for _, n := range fieldNames {
+		if fields.Has(n.field) {
+			s.watchers[n.field]++
+		}
+	}
+	s.mu.Unlock()
+	s.wakeUp(idle)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for _, n := range fieldNames {
+				if fields.Has(n.field) {
+					s.watchers[n.field]--
+				}
+			}
+		})
+	}
+}
+
+// wait returns the latest snapshot of b with fields, waiting for a new one if they were
+// not being collected.
+func wait[T any](ctx context.Context, s *SystemInfoService, b *Broadcast[T], fields Fields) (*Snapshot[T], <-chan struct{}, error) {
+	var since time.Time
+	now := time.Now()
+	if s.request(fields) != 0 {
+		since = now // the latest snapshot, if any, is outdated
+	}
+	return b.WaitFor(ctx, fields, since)
+}
+
+// GetStaticInfo returns the static info, waiting for it to be collected.
+func (s *SystemInfoService) GetStaticInfo(ctx context.Context) (StaticInfo, error) {
+	snap, _, err := s.Static.Wait(ctx)
+	if err != nil {
+		return StaticInfo{}, err
+	}
+	return snap.Value, snap.Err
+}
+
+// GetMetrics returns the latest metrics with at least fields (the others are zero), and a
+// channel closed when newer ones are collected.
+func (s *SystemInfoService) GetMetrics(ctx context.Context, fields Fields) (Metrics, <-chan struct{}, error) {
+	snap, next, err := wait(ctx, s, &s.Metrics, fields&MetricsFields)
+	if err != nil {
+		return Metrics{}, nil, err
+	}
+	return snap.Value, next, snap.Err
+}
+
+func (s *SystemInfoService) GetProcesses(ctx context.Context) ([]Process, <-chan struct{}, error) {
+	snap, next, err := wait(ctx, s, &s.Processes, FieldProcesses)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snap.Value, next, snap.Err
+}
+
+func (s *SystemInfoService) GetServices(ctx context.Context) ([]Service, <-chan struct{}, error) {
+	snap, next, err := wait(ctx, s, &s.Services, FieldServices)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snap.Value, next, snap.Err
+}
+
+// GetSystemInfo returns the static info and the latest fields as one SystemInfo, waiting
+// for the fields that were not being collected. Processes and services are empty if they
+// could not be collected.
+func (s *SystemInfoService) GetSystemInfo(ctx context.Context, fields Fields) (*SystemInfo, error) {
+	var info SystemInfo
+	var err error
+	if info.StaticInfo, err = s.GetStaticInfo(ctx); err != nil {
+		return nil, err
+	}
+	if fields&MetricsFields != 0 {
+		if info.Metrics, _, err = s.GetMetrics(ctx, fields); err != nil {
+			return nil, err
+		}
+	}
+	if fields.Has(FieldProcesses) {
+		info.Processes, _, _ = s.GetProcesses(ctx)
+	}
+	if fields.Has(FieldServices) {
+		info.Services, _, _ = s.GetServices(ctx)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &info, nil
+}
+
+// SetIntervals changes how often system info is collected, from the next collection.
+func (s *SystemInfoService) SetIntervals(intervals Intervals) {
+	s.intervals.Store(&intervals)
+}
+
+// NewSystemInfoService returns a service collecting the info of sys. Call Run to start
+// collecting.
+func NewSystemInfoService(sys System, intervals Intervals) *SystemInfoService {
+	s := &SystemInfoService{
+		sys:       sys,
+		requested: make(map[Fields]time.Time),
+		watchers:  make(map[Fields]int),
+		wake: map[Fields]chan struct{}{
+			MetricsFields:  make(chan struct{}, 1),
+			FieldProcesses: make(chan struct{}, 1),
+			FieldServices:  make(chan struct{}, 1),
+		},
+	}
+	s.SetIntervals(intervals)
+	return s
+}
+
+// sleep waits for d or for wake, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration, wake <-chan struct{}) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	case <-wake:
+		return true
+	}
+}
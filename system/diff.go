@@ -0,0 +1,106 @@
+package system
+
+import "slices"
+
+// ProcessDiff is what changed between two lists of processes, keyed by PID.
+type ProcessDiff struct {
+	Added   []Process `json:"added,omitempty"`
+	Removed []int32   `json:"removed,omitempty"` // pids
+	Changed []Process `json:"changed,omitempty"`
+}
+
+func (d ProcessDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffProcesses returns the changes that turn old into cur.
+func DiffProcesses(old, cur []Process) ProcessDiff {
+	var d ProcessDiff
+	prev := make(map[int32]Process, len(old))
+	for _, p := range old {
+		prev[p.PID] = p
+	}
+	for _, p := range cur {
+		o, ok := prev[p.PID]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, p)
+		case !o.equal(p):
+			d.Changed = append(d.Changed, p)
+		}
+		delete(prev, p.PID)
+	}
+	for pid := range prev {
+		d.Removed = append(d.Removed, pid)
+	}
+	slices.Sort(d.Removed)
+	return d
+}
+
+func (p Process) equal(q Process) bool {
+	return p.PID == q.PID && p.Name == q.Name && p.Status == q.Status && p.Threads == q.Threads &&
+		p.CPUPercent == q.CPUPercent && p.MemoryPercent == q.MemoryPercent && p.ParentPID == q.ParentPID &&
+		p.NumFDs == q.NumFDs && slices.Equal(p.ChildrenPIDs, q.ChildrenPIDs) &&
+		p.DiskReadRate == q.DiskReadRate && p.DiskWriteRate == q.DiskWriteRate &&
+		p.VoluntaryCtxSwitchRate == q.VoluntaryCtxSwitchRate && p.InvoluntaryCtxSwitchRate == q.InvoluntaryCtxSwitchRate
+}
+
+// ServiceDiff is what changed between two lists of services, keyed by name.
+type ServiceDiff struct {
+	Added   []Service `json:"added,omitempty"`
+	Removed []string  `json:"removed,omitempty"` // names
+	Changed []Service `json:"changed,omitempty"`
+}
+
+func (d ServiceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffServices returns the changes that turn old into cur.
+func DiffServices(old, cur []Service) ServiceDiff {
+	var d ServiceDiff
+	prev := make(map[string]Service, len(old))
+	for _, s := range old {
+		prev[s.Name] = s
+	}
+	for _, s := range cur {
+		o, ok := prev[s.Name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, s)
+		case o != s:
+			d.Changed = append(d.Changed, s)
+		}
+		delete(prev, s.Name)
+	}
+	for name := range prev {
+		d.Removed = append(d.Removed, name)
+	}
+	slices.Sort(d.Removed)
+	return d
+}
+
+// Map returns the metrics by their json name.
+func (d Metrics) Map() map[string]any {
+	return map[string]any{
+		"cpu_usage":       d.CPU_Usage,
+		"cpu_temp":        d.CPU_Temp,
+		"memory_used":     d.MemoryUsed,
+		"storage_used":    d.StorageUsed,
+		"battery_temp":    d.BatteryTemp,
+		"battery_percent": d.BatteryPercent,
+		"battery_status":  d.BatteryStatus,
+		"uptime":          d.Uptime,
+	}
+}
+
+// DiffMetrics returns the metrics of cur that differ from old.
+func DiffMetrics(old, cur map[string]any) map[string]any {
+	d := make(map[string]any)
+	for k, v := range cur {
+		if old[k] != v {
+			d[k] = v
+		}
+	}
+	return d
+}
@@ -0,0 +1,99 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fields is a set of the fields of DynamicInfo, so that only the requested ones are
+// collected.
+type Fields uint16
+
+const (
+	FieldCPUUsage Fields = 1 << iota
+	FieldCPUTemp
+	FieldMemoryUsed
+	FieldStorageUsed
+	FieldBatteryTemp
+	FieldBatteryPercent
+	FieldBatteryStatus
+	FieldUptime
+	FieldProcesses
+	FieldServices
+
+	BatteryFields = FieldBatteryTemp | FieldBatteryPercent | FieldBatteryStatus
+	MetricsFields = FieldCPUUsage | FieldCPUTemp | FieldMemoryUsed | FieldStorageUsed | BatteryFields | FieldUptime
+	AllFields     = MetricsFields | FieldProcesses | FieldServices
+)
+
+// fieldNames are the json names of the fields, in the order of DynamicInfo.
+var fieldNames = []struct {
+	name  string
+	field Fields
+}{
+	{"cpu_usage", FieldCPUUsage},
+	{"cpu_temp", FieldCPUTemp},
+	{"memory_used", FieldMemoryUsed},
+	{"storage_used", FieldStorageUsed},
+	{"battery_temp", FieldBatteryTemp},
+	{"battery_percent", FieldBatteryPercent},
+	{"battery_status", FieldBatteryStatus},
+	{"uptime", FieldUptime},
+	{"processes", FieldProcesses},
+	{"services", FieldServices},
+}
+
+// ParseFields parses a comma-separated list of json field names, e.g.
+// cpu_usage,memory_used,services. An empty list is every field.
+func ParseFields(s string) (Fields, error) {
+	if strings.TrimSpace(s) == "" {
+		return AllFields, nil
+	}
+	var fields Fields
+	for _, name := range strings.Split(s, ",") {
+		f, ok := fieldByName(strings.TrimSpace(name))
+		if !ok {
+			return 0, fmt.Errorf("unknown field %q, expected some of %s", name, AllFields)
+		}
+		fields |= f
+	}
+	return fields, nil
+}
+
+func fieldByName(name string) (Fields, bool) {
+	for _, f := range fieldNames {
+		if f.name == name {
+			return f.field, true
+		}
+	}
+	return 0, false
+}
+
+// Has reports whether every field of g is in f.
+func (f Fields) Has(g Fields) bool {
+	return f&g == g
+}
+
+// String returns the json names of the fields separated by commas.
+func (f Fields) String() string {
+	var names []string
+	for _, n := range fieldNames {
+		if f.Has(n.field) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Select returns the fields of d by their json name.
+func (d DynamicInfo) Select(fields Fields) map[string]any {
+	m := d.Metrics.Map()
+	m["processes"] = d.Processes
+	m["services"] = d.Services
+	for _, n := range fieldNames {
+		if !fields.Has(n.field) {
+			delete(m, n.name)
+		}
+	}
+	return m
+}
@@ -0,0 +1,176 @@
+package system
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+type PowerAction string
+
+const (
+	PowerActionShutdown             PowerAction = "shutdown"
+	PowerActionReboot               PowerAction = "reboot"
+	PowerActionSuspend              PowerAction = "suspend"
+	PowerActionHibernate            PowerAction = "hibernate"
+	PowerActionHybridSleep          PowerAction = "hybrid-sleep"
+	PowerActionSuspendThenHibernate PowerAction = "suspend-then-hibernate"
+)
+
+var ErrInvalidPowerAction = errors.New("invalid power action")
+var ErrUnsupportedPowerAction = errors.New("power action not supported by this system")
+var ErrScheduledActionNotFound = errors.New("scheduled power action not found")
+
+// Run performs the power action on sys immediately.
+func (a PowerAction) Run(sys System) error {
+	switch a {
+	case PowerActionShutdown:
+		return sys.Shutdown()
+	case PowerActionReboot:
+		return sys.Reboot()
+	case PowerActionSuspend:
+		return sys.Suspend()
+	case PowerActionHibernate:
+		return sys.Hibernate()
+	case PowerActionHybridSleep:
+		return sys.HybridSleep()
+	case PowerActionSuspendThenHibernate:
+		return sys.SuspendThenHibernate()
+	default:
+		return ErrInvalidPowerAction
+	}
+}
+
+func (a PowerAction) Valid() bool {
+	switch a {
+	case PowerActionShutdown, PowerActionReboot, PowerActionSuspend, PowerActionHibernate,
+		PowerActionHybridSleep, PowerActionSuspendThenHibernate:
+		return true
+	}
+	return false
+}
+
+// CheckSupported returns ErrInvalidPowerAction if a isn't a power action, or an error
+// wrapping ErrUnsupportedPowerAction that lists the supported actions if it isn't one of them.
+func (a PowerAction) CheckSupported(supported []PowerAction) error {
+	if !a.Valid() {
+		return ErrInvalidPowerAction
+	}
+	if !slices.Contains(supported, a) {
+		return fmt.Errorf("%w: %s, supported actions: %v", ErrUnsupportedPowerAction, a, supported)
+	}
+	return nil
+}
+
+type ScheduledPowerAction struct {
+	ID        string      `json:"id"`
+	Action    PowerAction `json:"action"`
+	At        time.Time   `json:"at"`                // when the action will run
+	Message   string      `json:"message,omitempty"` // wall message sent to logged-in users
+	CreatedAt time.Time   `json:"created_at"`
+
+	timer *time.Timer
+}
+
+// PowerScheduler runs power actions at a later time. Pending actions are kept
+// in memory only; they do not survive a restart of the server.
+type PowerScheduler struct {
+	sys     System
+	mu      sync.Mutex
+	pending map[string]*ScheduledPowerAction
+}
+
+// Schedule schedules action to run at the given time. If message is non-empty, it is
+// broadcast to logged-in users now and again if the action is cancelled.
+func (ps *PowerScheduler) Schedule(action PowerAction, at time.Time, message string) (ScheduledPowerAction, error) {
+	if !action.Valid() {
+		return ScheduledPowerAction{}, ErrInvalidPowerAction
+	}
+	if at.Before(time.Now()) {
+		return ScheduledPowerAction{}, errors.New("scheduled time is in the past")
+	}
+	id, err := randomID()
+	if err != nil {
+		return ScheduledPowerAction{}, err
+	}
+	spa := &ScheduledPowerAction{
+		ID:        id,
+		Action:    action,
+		At:        at,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+	if message != "" {
+		wall := fmt.Sprintf("scheduled %s at %s: %s", action, at.Format(time.RFC1123), message)
+		if err := ps.sys.WallMessage(wall); err != nil {
+			slog.Error("send wall message", "error", err)
+		}
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	spa.timer = time.AfterFunc(time.Until(at), func() {
+		ps.mu.Lock()
+		delete(ps.pending, id)
+		ps.mu.Unlock()
+		slog.Info("running scheduled power action", "id", id, "action", action)
+		if err := action.Run(ps.sys); err != nil {
+			slog.Error("scheduled power action", "id", id, "action", action, "error", err)
+		}
+	})
+	ps.pending[id] = spa
+	return *spa, nil
+}
+
+// Pending returns the scheduled actions that have not run yet, soonest first.
+func (ps *PowerScheduler) Pending() []ScheduledPowerAction {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	actions := make([]ScheduledPowerAction, 0, len(ps.pending))
+	for _, spa := range ps.pending {
+		actions = append(actions, *spa)
+	}
+	slices.SortFunc(actions, func(a, b ScheduledPowerAction) int {
+		return a.At.Compare(b.At)
+	})
+	return actions
+}
+
+func (ps *PowerScheduler) Cancel(id string) error {
+	ps.mu.Lock()
+	spa, ok := ps.pending[id]
+	if !ok || !spa.timer.Stop() {
+		ps.mu.Unlock()
+		return ErrScheduledActionNotFound
+	}
+	delete(ps.pending, id)
+	ps.mu.Unlock()
+
+	if spa.Message != "" {
+		wall := fmt.Sprintf("scheduled %s at %s has been cancelled", spa.Action, spa.At.Format(time.RFC1123))
+		if err := ps.sys.WallMessage(wall); err != nil {
+			slog.Error("send wall message", "error", err)
+		}
+	}
+	return nil
+}
+
+func NewPowerScheduler(sys System) *PowerScheduler {
+	return &PowerScheduler{
+		sys:     sys,
+		pending: make(map[string]*ScheduledPowerAction),
+	}
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
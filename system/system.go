@@ -2,26 +2,39 @@ package system
 
 import (
 	"io"
+	"syscall"
 	"time"
 )
 
 type System interface {
-	GetSystemInfo() (*SystemInfo, error)
+	GetStaticInfo() (StaticInfo, error)
+	GetMetrics(fields Fields) (Metrics, error) // only the metrics in fields
+	GetProcesses() ([]Process, error)
+	GetServices() ([]Service, error)
 	GetSystemLogs(logOptions LogOptions) (io.ReadCloser, error)
 	GetServiceLog(serviceName string, logOptions LogOptions) (io.ReadCloser, error)
 
+	SignalProcess(pid int32, signal syscall.Signal) error
+
 	StartService(serviceName string) error
 	StopService(serviceName string) error
 	RestartService(serviceName string) error
 
 	Shutdown() error
 	Reboot() error
+	Suspend() error
+	Hibernate() error
+	HybridSleep() error
+	SuspendThenHibernate() error
+	WallMessage(message string) error
 }
 
 type LogOptions struct {
 	Since        *time.Time
 	Until        *time.Time
 	ThisBootOnly bool
+	Lines        int  // only the last lines, all if 0
+	Follow       bool // keep streaming new entries
 }
 
 type SystemInfo struct {
@@ -45,9 +58,17 @@ type StaticInfo struct {
 
 	HasBattery bool   `json:"has_battery"`       // whether the system has a battery
 	Battery    string `json:"battery,omitempty"` // battery model
+
+	PowerActions []PowerAction `json:"power_actions"` // power actions supported by the system
 }
 
 type DynamicInfo struct {
+	Metrics
+	Processes []Process `json:"processes"` // list of running processes
+	Services  []Service `json:"services"`  // list of services
+}
+
+type Metrics struct {
 	CPU_Usage float64 `json:"cpu_usage"` // cpu usage percentage
 	CPU_Temp  float64 `json:"cpu_temp"`  // cpu temperature in celsius
 
@@ -58,9 +79,7 @@ type DynamicInfo struct {
 	BatteryPercent float64 `json:"battery_percent"` // battery percentage
 	BatteryStatus  string  `json:"battery_status"`  // battery status (e.g., charging, discharging, full)
 
-	Processes []Process `json:"processes"` // list of running processes
-	Services  []Service `json:"services"`  // list of services
-	Uptime    uint64    `json:"uptime"`    // system uptime in seconds
+	Uptime uint64 `json:"uptime"` // system uptime in seconds
 }
 
 type Service struct {
@@ -74,38 +93,15 @@ type Process struct {
 	Name          string  `json:"name"`                    // process name
 	Status        string  `json:"status"`                  // process status (e.g., running, sleep, stop, blocked)
 	Threads       int32   `json:"threads"`                 // number of threads
-	CPUPercent    float64 `json:"cpu_percent"`             // cpu usage as a percentage of total CPU
+	CPUPercent    float64 `json:"cpu_percent"`             // cpu usage since the previous sample as a percentage of total CPU
 	MemoryPercent float32 `json:"memory_percent"`          // memory usage as a percentage of total system memory
 	ParentPID     int32   `json:"parent_pid,omitempty"`    // parent process ID
 	NumFDs        int32   `json:"num_fds,omitempty"`       // number of file descriptors
 	ChildrenPIDs  []int32 `json:"children_pids,omitempty"` // child process IDs
-}
-
-const InfoRefreshInterval = 1 * time.Second
-
-type SystemInfoService struct {
-	sys                 System
-	lastInfo            *SystemInfo
-	timeOfLastInfo      time.Time
-	infoRefreshInterval time.Duration
-}
-
-func (s *SystemInfoService) GetSystemInfo() (*SystemInfo, error) {
-	if time.Since(s.timeOfLastInfo) < s.infoRefreshInterval && s.lastInfo != nil {
-		return s.lastInfo, nil
-	}
-	info, err := s.sys.GetSystemInfo()
-	if err != nil {
-		return nil, err
-	}
-	s.lastInfo = info
-	s.timeOfLastInfo = time.Now()
-	return s.lastInfo, nil
-}
 
-func NewSystemInfoService(sys System, infoRefreshInterval time.Duration) *SystemInfoService {
-	return &SystemInfoService{
-		sys:                 sys,
-		infoRefreshInterval: infoRefreshInterval,
-	}
+	// since the previous sample, -1 if unknown (e.g. i/o of other users' processes without root)
+	DiskReadRate             float64 `json:"disk_read_rate"`              // bytes read from storage per second
+	DiskWriteRate            float64 `json:"disk_write_rate"`             // bytes written to storage per second
+	VoluntaryCtxSwitchRate   float64 `json:"voluntary_ctx_switch_rate"`   // per second, e.g. waiting for i/o
+	InvoluntaryCtxSwitchRate float64 `json:"involuntary_ctx_switch_rate"` // per second, preempted by the scheduler
 }
@@ -0,0 +1,5 @@
+// Package systempb is the gRPC api of the server, generated from system.proto.
+package systempb
+
+// requires buf, protoc-gen-go and protoc-gen-go-grpc in $PATH.
+//go:generate buf generate
@@ -0,0 +1,1910 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: system.proto
+
+// the gRPC api of system, mirroring the REST api under /api/v1. authenticate with an api
+// token in the "authorization" metadata ("Bearer sys_...") or a client certificate.
+
+package systempb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Signal int32
+
+const (
+	Signal_SIGNAL_UNSPECIFIED Signal = 0
+	Signal_SIGNAL_SIGKILL     Signal = 1
+	Signal_SIGNAL_SIGTERM     Signal = 2
+	Signal_SIGNAL_SIGSTOP     Signal = 3
+	Signal_SIGNAL_SIGCONT     Signal = 4
+	Signal_SIGNAL_SIGQUIT     Signal = 5
+)
+
+// Enum value maps for Signal.
+var (
+	Signal_name = map[int32]string{
+		0: "SIGNAL_UNSPECIFIED",
+		1: "SIGNAL_SIGKILL",
+		2: "SIGNAL_SIGTERM",
+		3: "SIGNAL_SIGSTOP",
+		4: "SIGNAL_SIGCONT",
+		5: "SIGNAL_SIGQUIT",
+	}
+	Signal_value = map[string]int32{
+		"SIGNAL_UNSPECIFIED": 0,
+		"SIGNAL_SIGKILL":     1,
+		"SIGNAL_SIGTERM":     2,
+		"SIGNAL_SIGSTOP":     3,
+		"SIGNAL_SIGCONT":     4,
+		"SIGNAL_SIGQUIT":     5,
+	}
+)
+
+func (x Signal) Enum() *Signal {
+	p := new(Signal)
+	*p = x
+	return p
+}
+
+func (x Signal) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Signal) Descriptor() protoreflect.EnumDescriptor {
+	return file_system_proto_enumTypes[0].Descriptor()
+}
+
+func (Signal) Type() protoreflect.EnumType {
+	return &file_system_proto_enumTypes[0]
+}
+
+func (x Signal) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Signal.Descriptor instead.
+func (Signal) EnumDescriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{0}
+}
+
+type ServiceAction int32
+
+const (
+	ServiceAction_SERVICE_ACTION_UNSPECIFIED ServiceAction = 0
+	ServiceAction_SERVICE_ACTION_START       ServiceAction = 1
+	ServiceAction_SERVICE_ACTION_STOP        ServiceAction = 2
+	ServiceAction_SERVICE_ACTION_RESTART     ServiceAction = 3
+)
+
+// Enum value maps for ServiceAction.
+var (
+	ServiceAction_name = map[int32]string{
+		0: "SERVICE_ACTION_UNSPECIFIED",
+		1: "SERVICE_ACTION_START",
+		2: "SERVICE_ACTION_STOP",
+		3: "SERVICE_ACTION_RESTART",
+	}
+	ServiceAction_value = map[string]int32{
+		"SERVICE_ACTION_UNSPECIFIED": 0,
+		"SERVICE_ACTION_START":       1,
+		"SERVICE_ACTION_STOP":        2,
+		"SERVICE_ACTION_RESTART":     3,
+	}
+)
+
+func (x ServiceAction) Enum() *ServiceAction {
+	p := new(ServiceAction)
+	*p = x
+	return p
+}
+
+func (x ServiceAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ServiceAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_system_proto_enumTypes[1].Descriptor()
+}
+
+func (ServiceAction) Type() protoreflect.EnumType {
+	return &file_system_proto_enumTypes[1]
+}
+
+func (x ServiceAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ServiceAction.Descriptor instead.
+func (ServiceAction) EnumDescriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{1}
+}
+
+type PowerAction int32
+
+const (
+	PowerAction_POWER_ACTION_UNSPECIFIED            PowerAction = 0
+	PowerAction_POWER_ACTION_SHUTDOWN               PowerAction = 1
+	PowerAction_POWER_ACTION_REBOOT                 PowerAction = 2
+	PowerAction_POWER_ACTION_SUSPEND                PowerAction = 3
+	PowerAction_POWER_ACTION_HIBERNATE              PowerAction = 4
+	PowerAction_POWER_ACTION_HYBRID_SLEEP           PowerAction = 5
+	PowerAction_POWER_ACTION_SUSPEND_THEN_HIBERNATE PowerAction = 6
+)
+
+// Enum value maps for PowerAction.
+var (
+	PowerAction_name = map[int32]string{
+		0: "POWER_ACTION_UNSPECIFIED",
+		1: "POWER_ACTION_SHUTDOWN",
+		2: "POWER_ACTION_REBOOT",
+		3: "POWER_ACTION_SUSPEND",
+		4: "POWER_ACTION_HIBERNATE",
+		5: "POWER_ACTION_HYBRID_SLEEP",
+		6: "POWER_ACTION_SUSPEND_THEN_HIBERNATE",
+	}
+	PowerAction_value = map[string]int32{
+		"POWER_ACTION_UNSPECIFIED":            0,
+		"POWER_ACTION_SHUTDOWN":               1,
+		"POWER_ACTION_REBOOT":                 2,
+		"POWER_ACTION_SUSPEND":                3,
+		"POWER_ACTION_HIBERNATE":              4,
+		"POWER_ACTION_HYBRID_SLEEP":           5,
+		"POWER_ACTION_SUSPEND_THEN_HIBERNATE": 6,
+	}
+)
+
+func (x PowerAction) Enum() *PowerAction {
+	p := new(PowerAction)
+	*p = x
+	return p
+}
+
+func (x PowerAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PowerAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_system_proto_enumTypes[2].Descriptor()
+}
+
+func (PowerAction) Type() protoreflect.EnumType {
+	return &file_system_proto_enumTypes[2]
+}
+
+func (x PowerAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PowerAction.Descriptor instead.
+func (PowerAction) EnumDescriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{2}
+}
+
+type SystemInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Static        *StaticInfo            `protobuf:"bytes,1,opt,name=static,proto3" json:"static,omitempty"`
+	Dynamic       *DynamicInfo           `protobuf:"bytes,2,opt,name=dynamic,proto3" json:"dynamic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SystemInfo) Reset() {
+	*x = SystemInfo{}
+	mi := &file_system_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SystemInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SystemInfo) ProtoMessage() {}
+
+func (x *SystemInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SystemInfo.ProtoReflect.Descriptor instead.
+func (*SystemInfo) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SystemInfo) GetStatic() *StaticInfo {
+	if x != nil {
+		return x.Static
+	}
+	return nil
+}
+
+func (x *SystemInfo) GetDynamic() *DynamicInfo {
+	if x != nil {
+		return x.Dynamic
+	}
+	return nil
+}
+
+type StaticInfo struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Os              string                 `protobuf:"bytes,1,opt,name=os,proto3" json:"os,omitempty"`
+	OsRelease       string                 `protobuf:"bytes,2,opt,name=os_release,json=osRelease,proto3" json:"os_release,omitempty"`
+	Hostname        string                 `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Cpu             string                 `protobuf:"bytes,4,opt,name=cpu,proto3" json:"cpu,omitempty"` // cpu model
+	NumCpu          int32                  `protobuf:"varint,5,opt,name=num_cpu,json=numCpu,proto3" json:"num_cpu,omitempty"`
+	Arch            string                 `protobuf:"bytes,6,opt,name=arch,proto3" json:"arch,omitempty"`
+	Memory          uint64                 `protobuf:"varint,7,opt,name=memory,proto3" json:"memory,omitempty"`                                          // total memory in bytes
+	StorageCapacity uint64                 `protobuf:"varint,8,opt,name=storage_capacity,json=storageCapacity,proto3" json:"storage_capacity,omitempty"` // total storage capacity in bytes
+	HasBattery      bool                   `protobuf:"varint,9,opt,name=has_battery,json=hasBattery,proto3" json:"has_battery,omitempty"`
+	Battery         string                 `protobuf:"bytes,10,opt,name=battery,proto3" json:"battery,omitempty"`                                                                  // battery model
+	PowerActions    []PowerAction          `protobuf:"varint,11,rep,packed,name=power_actions,json=powerActions,proto3,enum=system.v1.PowerAction" json:"power_actions,omitempty"` // supported by the system
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StaticInfo) Reset() {
+	*x = StaticInfo{}
+	mi := &file_system_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StaticInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StaticInfo) ProtoMessage() {}
+
+func (x *StaticInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StaticInfo.ProtoReflect.Descriptor instead.
+func (*StaticInfo) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StaticInfo) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *StaticInfo) GetOsRelease() string {
+	if x != nil {
+		return x.OsRelease
+	}
+	return ""
+}
+
+func (x *StaticInfo) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *StaticInfo) GetCpu() string {
+	if x != nil {
+		return x.Cpu
+	}
+	return ""
+}
+
+func (x *StaticInfo) GetNumCpu() int32 {
+	if x != nil {
+		return x.NumCpu
+	}
+	return 0
+}
+
+func (x *StaticInfo) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *StaticInfo) GetMemory() uint64 {
+	if x != nil {
+		return x.Memory
+	}
+	return 0
+}
+
+func (x *StaticInfo) GetStorageCapacity() uint64 {
+	if x != nil {
+		return x.StorageCapacity
+	}
+	return 0
+}
+
+func (x *StaticInfo) GetHasBattery() bool {
+	if x != nil {
+		return x.HasBattery
+	}
+	return false
+}
+
+func (x *StaticInfo) GetBattery() string {
+	if x != nil {
+		return x.Battery
+	}
+	return ""
+}
+
+func (x *StaticInfo) GetPowerActions() []PowerAction {
+	if x != nil {
+		return x.PowerActions
+	}
+	return nil
+}
+
+type DynamicInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CpuUsage       float64                `protobuf:"fixed64,1,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`          // percentage
+	CpuTemp        float64                `protobuf:"fixed64,2,opt,name=cpu_temp,json=cpuTemp,proto3" json:"cpu_temp,omitempty"`             // celsius
+	MemoryUsed     uint64                 `protobuf:"varint,3,opt,name=memory_used,json=memoryUsed,proto3" json:"memory_used,omitempty"`     // bytes
+	StorageUsed    uint64                 `protobuf:"varint,4,opt,name=storage_used,json=storageUsed,proto3" json:"storage_used,omitempty"`  // bytes
+	BatteryTemp    float64                `protobuf:"fixed64,5,opt,name=battery_temp,json=batteryTemp,proto3" json:"battery_temp,omitempty"` // celsius
+	BatteryPercent float64                `protobuf:"fixed64,6,opt,name=battery_percent,json=batteryPercent,proto3" json:"battery_percent,omitempty"`
+	BatteryStatus  string                 `protobuf:"bytes,7,opt,name=battery_status,json=batteryStatus,proto3" json:"battery_status,omitempty"` // e.g. charging, discharging, full
+	Processes      []*Process             `protobuf:"bytes,8,rep,name=processes,proto3" json:"processes,omitempty"`
+	Services       []*Service             `protobuf:"bytes,9,rep,name=services,proto3" json:"services,omitempty"`
+	Uptime         uint64                 `protobuf:"varint,10,opt,name=uptime,proto3" json:"uptime,omitempty"` // seconds
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DynamicInfo) Reset() {
+	*x = DynamicInfo{}
+	mi := &file_system_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DynamicInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DynamicInfo) ProtoMessage() {}
+
+func (x *DynamicInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DynamicInfo.ProtoReflect.Descriptor instead.
+func (*DynamicInfo) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DynamicInfo) GetCpuUsage() float64 {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return 0
+}
+
+func (x *DynamicInfo) GetCpuTemp() float64 {
+	if x != nil {
+		return x.CpuTemp
+	}
+	return 0
+}
+
+func (x *DynamicInfo) GetMemoryUsed() uint64 {
+	if x != nil {
+		return x.MemoryUsed
+	}
+	return 0
+}
+
+func (x *DynamicInfo) GetStorageUsed() uint64 {
+	if x != nil {
+		return x.StorageUsed
+	}
+	return 0
+}
+
+func (x *DynamicInfo) GetBatteryTemp() float64 {
+	if x != nil {
+		return x.BatteryTemp
+	}
+	return 0
+}
+
+func (x *DynamicInfo) GetBatteryPercent() float64 {
+	if x != nil {
+		return x.BatteryPercent
+	}
+	return 0
+}
+
+func (x *DynamicInfo) GetBatteryStatus() string {
+	if x != nil {
+		return x.BatteryStatus
+	}
+	return ""
+}
+
+func (x *DynamicInfo) GetProcesses() []*Process {
+	if x != nil {
+		return x.Processes
+	}
+	return nil
+}
+
+func (x *DynamicInfo) GetServices() []*Service {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *DynamicInfo) GetUptime() uint64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+type Process struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pid           int32                  `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // e.g. running, sleep, stop
+	Threads       int32                  `protobuf:"varint,4,opt,name=threads,proto3" json:"threads,omitempty"`
+	CpuPercent    float64                `protobuf:"fixed64,5,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"`
+	MemoryPercent float32                `protobuf:"fixed32,6,opt,name=memory_percent,json=memoryPercent,proto3" json:"memory_percent,omitempty"`
+	ParentPid     int32                  `protobuf:"varint,7,opt,name=parent_pid,json=parentPid,proto3" json:"parent_pid,omitempty"`
+	NumFds        int32                  `protobuf:"varint,8,opt,name=num_fds,json=numFds,proto3" json:"num_fds,omitempty"`
+	ChildrenPids  []int32                `protobuf:"varint,9,rep,packed,name=children_pids,json=childrenPids,proto3" json:"children_pids,omitempty"`
+	// since the previous sample, -1 if unknown
+	DiskReadRate             float64 `protobuf:"fixed64,10,opt,name=disk_read_rate,json=diskReadRate,proto3" json:"disk_read_rate,omitempty"`                                       // bytes per second
+	DiskWriteRate            float64 `protobuf:"fixed64,11,opt,name=disk_write_rate,json=diskWriteRate,proto3" json:"disk_write_rate,omitempty"`                                    // bytes per second
+	VoluntaryCtxSwitchRate   float64 `protobuf:"fixed64,12,opt,name=voluntary_ctx_switch_rate,json=voluntaryCtxSwitchRate,proto3" json:"voluntary_ctx_switch_rate,omitempty"`       // per second
+	InvoluntaryCtxSwitchRate float64 `protobuf:"fixed64,13,opt,name=involuntary_ctx_switch_rate,json=involuntaryCtxSwitchRate,proto3" json:"involuntary_ctx_switch_rate,omitempty"` // per second
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *Process) Reset() {
+	*x = Process{}
+	mi := &file_system_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Process) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Process) ProtoMessage() {}
+
+func (x *Process) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Process.ProtoReflect.Descriptor instead.
+func (*Process) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Process) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *Process) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Process) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Process) GetThreads() int32 {
+	if x != nil {
+		return x.Threads
+	}
+	return 0
+}
+
+func (x *Process) GetCpuPercent() float64 {
+	if x != nil {
+		return x.CpuPercent
+	}
+	return 0
+}
+
+func (x *Process) GetMemoryPercent() float32 {
+	if x != nil {
+		return x.MemoryPercent
+	}
+	return 0
+}
+
+func (x *Process) GetParentPid() int32 {
+	if x != nil {
+		return x.ParentPid
+	}
+	return 0
+}
+
+func (x *Process) GetNumFds() int32 {
+	if x != nil {
+		return x.NumFds
+	}
+	return 0
+}
+
+func (x *Process) GetChildrenPids() []int32 {
+	if x != nil {
+		return x.ChildrenPids
+	}
+	return nil
+}
+
+func (x *Process) GetDiskReadRate() float64 {
+	if x != nil {
+		return x.DiskReadRate
+	}
+	return 0
+}
+
+func (x *Process) GetDiskWriteRate() float64 {
+	if x != nil {
+		return x.DiskWriteRate
+	}
+	return 0
+}
+
+func (x *Process) GetVoluntaryCtxSwitchRate() float64 {
+	if x != nil {
+		return x.VoluntaryCtxSwitchRate
+	}
+	return 0
+}
+
+func (x *Process) GetInvoluntaryCtxSwitchRate() float64 {
+	if x != nil {
+		return x.InvoluntaryCtxSwitchRate
+	}
+	return 0
+}
+
+type Service struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // e.g. running, failed, dead, exited
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Service) Reset() {
+	*x = Service{}
+	mi := &file_system_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Service) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Service) ProtoMessage() {}
+
+func (x *Service) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Service.ProtoReflect.Descriptor instead.
+func (*Service) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Service) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Service) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Service) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type GetInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInfoRequest) Reset() {
+	*x = GetInfoRequest{}
+	mi := &file_system_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInfoRequest) ProtoMessage() {}
+
+func (x *GetInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetInfoRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{5}
+}
+
+type WatchMetricsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// how often metrics are sent, at least one second. defaults to the websocket interval.
+	IntervalSeconds uint32 `protobuf:"varint,1,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	// leave out processes and services, which are most of the size of a message.
+	ExcludeProcesses bool `protobuf:"varint,2,opt,name=exclude_processes,json=excludeProcesses,proto3" json:"exclude_processes,omitempty"`
+	ExcludeServices  bool `protobuf:"varint,3,opt,name=exclude_services,json=excludeServices,proto3" json:"exclude_services,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *WatchMetricsRequest) Reset() {
+	*x = WatchMetricsRequest{}
+	mi := &file_system_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMetricsRequest) ProtoMessage() {}
+
+func (x *WatchMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMetricsRequest.ProtoReflect.Descriptor instead.
+func (*WatchMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchMetricsRequest) GetIntervalSeconds() uint32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+func (x *WatchMetricsRequest) GetExcludeProcesses() bool {
+	if x != nil {
+		return x.ExcludeProcesses
+	}
+	return false
+}
+
+func (x *WatchMetricsRequest) GetExcludeServices() bool {
+	if x != nil {
+		return x.ExcludeServices
+	}
+	return false
+}
+
+type GetProcessRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pid           int32                  `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProcessRequest) Reset() {
+	*x = GetProcessRequest{}
+	mi := &file_system_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProcessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProcessRequest) ProtoMessage() {}
+
+func (x *GetProcessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProcessRequest.ProtoReflect.Descriptor instead.
+func (*GetProcessRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetProcessRequest) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+type SignalProcessRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pid           int32                  `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Signal        Signal                 `protobuf:"varint,2,opt,name=signal,proto3,enum=system.v1.Signal" json:"signal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalProcessRequest) Reset() {
+	*x = SignalProcessRequest{}
+	mi := &file_system_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalProcessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalProcessRequest) ProtoMessage() {}
+
+func (x *SignalProcessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalProcessRequest.ProtoReflect.Descriptor instead.
+func (*SignalProcessRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SignalProcessRequest) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *SignalProcessRequest) GetSignal() Signal {
+	if x != nil {
+		return x.Signal
+	}
+	return Signal_SIGNAL_UNSPECIFIED
+}
+
+type SignalProcessResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalProcessResponse) Reset() {
+	*x = SignalProcessResponse{}
+	mi := &file_system_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalProcessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalProcessResponse) ProtoMessage() {}
+
+func (x *SignalProcessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalProcessResponse.ProtoReflect.Descriptor instead.
+func (*SignalProcessResponse) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{9}
+}
+
+type GetServiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServiceRequest) Reset() {
+	*x = GetServiceRequest{}
+	mi := &file_system_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServiceRequest) ProtoMessage() {}
+
+func (x *GetServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServiceRequest.ProtoReflect.Descriptor instead.
+func (*GetServiceRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetServiceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ControlServiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Action        ServiceAction          `protobuf:"varint,2,opt,name=action,proto3,enum=system.v1.ServiceAction" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlServiceRequest) Reset() {
+	*x = ControlServiceRequest{}
+	mi := &file_system_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlServiceRequest) ProtoMessage() {}
+
+func (x *ControlServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlServiceRequest.ProtoReflect.Descriptor instead.
+func (*ControlServiceRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ControlServiceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ControlServiceRequest) GetAction() ServiceAction {
+	if x != nil {
+		return x.Action
+	}
+	return ServiceAction_SERVICE_ACTION_UNSPECIFIED
+}
+
+type ControlServiceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlServiceResponse) Reset() {
+	*x = ControlServiceResponse{}
+	mi := &file_system_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlServiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlServiceResponse) ProtoMessage() {}
+
+func (x *ControlServiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlServiceResponse.ProtoReflect.Descriptor instead.
+func (*ControlServiceResponse) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{12}
+}
+
+type LogOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	Until         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=until,proto3" json:"until,omitempty"`
+	ThisBootOnly  bool                   `protobuf:"varint,3,opt,name=this_boot_only,json=thisBootOnly,proto3" json:"this_boot_only,omitempty"`
+	Lines         int32                  `protobuf:"varint,4,opt,name=lines,proto3" json:"lines,omitempty"`   // only the last lines, all if 0
+	Follow        bool                   `protobuf:"varint,5,opt,name=follow,proto3" json:"follow,omitempty"` // keep streaming new entries until the call is cancelled
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogOptions) Reset() {
+	*x = LogOptions{}
+	mi := &file_system_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogOptions) ProtoMessage() {}
+
+func (x *LogOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogOptions.ProtoReflect.Descriptor instead.
+func (*LogOptions) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LogOptions) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *LogOptions) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *LogOptions) GetThisBootOnly() bool {
+	if x != nil {
+		return x.ThisBootOnly
+	}
+	return false
+}
+
+func (x *LogOptions) GetLines() int32 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *LogOptions) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+type GetSystemLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Options       *LogOptions            `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSystemLogsRequest) Reset() {
+	*x = GetSystemLogsRequest{}
+	mi := &file_system_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSystemLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSystemLogsRequest) ProtoMessage() {}
+
+func (x *GetSystemLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSystemLogsRequest.ProtoReflect.Descriptor instead.
+func (*GetSystemLogsRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetSystemLogsRequest) GetOptions() *LogOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type GetServiceLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Options       *LogOptions            `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServiceLogsRequest) Reset() {
+	*x = GetServiceLogsRequest{}
+	mi := &file_system_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServiceLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServiceLogsRequest) ProtoMessage() {}
+
+func (x *GetServiceLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServiceLogsRequest.ProtoReflect.Descriptor instead.
+func (*GetServiceLogsRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetServiceLogsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetServiceLogsRequest) GetOptions() *LogOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type LogChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogChunk) Reset() {
+	*x = LogChunk{}
+	mi := &file_system_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogChunk) ProtoMessage() {}
+
+func (x *LogChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogChunk.ProtoReflect.Descriptor instead.
+func (*LogChunk) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *LogChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type RunPowerActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        PowerAction            `protobuf:"varint,1,opt,name=action,proto3,enum=system.v1.PowerAction" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunPowerActionRequest) Reset() {
+	*x = RunPowerActionRequest{}
+	mi := &file_system_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunPowerActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunPowerActionRequest) ProtoMessage() {}
+
+func (x *RunPowerActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunPowerActionRequest.ProtoReflect.Descriptor instead.
+func (*RunPowerActionRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RunPowerActionRequest) GetAction() PowerAction {
+	if x != nil {
+		return x.Action
+	}
+	return PowerAction_POWER_ACTION_UNSPECIFIED
+}
+
+type RunPowerActionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunPowerActionResponse) Reset() {
+	*x = RunPowerActionResponse{}
+	mi := &file_system_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunPowerActionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunPowerActionResponse) ProtoMessage() {}
+
+func (x *RunPowerActionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunPowerActionResponse.ProtoReflect.Descriptor instead.
+func (*RunPowerActionResponse) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{18}
+}
+
+type SchedulePowerActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        PowerAction            `protobuf:"varint,1,opt,name=action,proto3,enum=system.v1.PowerAction" json:"action,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // wall message sent to logged-in users
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchedulePowerActionRequest) Reset() {
+	*x = SchedulePowerActionRequest{}
+	mi := &file_system_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchedulePowerActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchedulePowerActionRequest) ProtoMessage() {}
+
+func (x *SchedulePowerActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchedulePowerActionRequest.ProtoReflect.Descriptor instead.
+func (*SchedulePowerActionRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SchedulePowerActionRequest) GetAction() PowerAction {
+	if x != nil {
+		return x.Action
+	}
+	return PowerAction_POWER_ACTION_UNSPECIFIED
+}
+
+func (x *SchedulePowerActionRequest) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *SchedulePowerActionRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ScheduledPowerAction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Action        PowerAction            `protobuf:"varint,2,opt,name=action,proto3,enum=system.v1.PowerAction" json:"action,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScheduledPowerAction) Reset() {
+	*x = ScheduledPowerAction{}
+	mi := &file_system_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduledPowerAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduledPowerAction) ProtoMessage() {}
+
+func (x *ScheduledPowerAction) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduledPowerAction.ProtoReflect.Descriptor instead.
+func (*ScheduledPowerAction) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ScheduledPowerAction) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ScheduledPowerAction) GetAction() PowerAction {
+	if x != nil {
+		return x.Action
+	}
+	return PowerAction_POWER_ACTION_UNSPECIFIED
+}
+
+func (x *ScheduledPowerAction) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *ScheduledPowerAction) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ScheduledPowerAction) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListScheduledPowerActionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScheduledPowerActionsRequest) Reset() {
+	*x = ListScheduledPowerActionsRequest{}
+	mi := &file_system_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScheduledPowerActionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScheduledPowerActionsRequest) ProtoMessage() {}
+
+func (x *ListScheduledPowerActionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScheduledPowerActionsRequest.ProtoReflect.Descriptor instead.
+func (*ListScheduledPowerActionsRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{21}
+}
+
+type ListScheduledPowerActionsResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Actions       []*ScheduledPowerAction `protobuf:"bytes,1,rep,name=actions,proto3" json:"actions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScheduledPowerActionsResponse) Reset() {
+	*x = ListScheduledPowerActionsResponse{}
+	mi := &file_system_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScheduledPowerActionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScheduledPowerActionsResponse) ProtoMessage() {}
+
+func (x *ListScheduledPowerActionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScheduledPowerActionsResponse.ProtoReflect.Descriptor instead.
+func (*ListScheduledPowerActionsResponse) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListScheduledPowerActionsResponse) GetActions() []*ScheduledPowerAction {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+type CancelScheduledPowerActionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelScheduledPowerActionRequest) Reset() {
+	*x = CancelScheduledPowerActionRequest{}
+	mi := &file_system_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelScheduledPowerActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelScheduledPowerActionRequest) ProtoMessage() {}
+
+func (x *CancelScheduledPowerActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelScheduledPowerActionRequest.ProtoReflect.Descriptor instead.
+func (*CancelScheduledPowerActionRequest) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CancelScheduledPowerActionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CancelScheduledPowerActionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelScheduledPowerActionResponse) Reset() {
+	*x = CancelScheduledPowerActionResponse{}
+	mi := &file_system_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelScheduledPowerActionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelScheduledPowerActionResponse) ProtoMessage() {}
+
+func (x *CancelScheduledPowerActionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_system_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelScheduledPowerActionResponse.ProtoReflect.Descriptor instead.
+func (*CancelScheduledPowerActionResponse) Descriptor() ([]byte, []int) {
+	return file_system_proto_rawDescGZIP(), []int{24}
+}
+
+var File_system_proto protoreflect.FileDescriptor
+
+const file_system_proto_rawDesc = "" +
+	"\n" +
+	"\fsystem.proto\x12\tsystem.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"m\n" +
+	"\n" +
+	"SystemInfo\x12-\n" +
+	"\x06static\x18\x01 \x01(\v2\x15.system.v1.StaticInfoR\x06static\x120\n" +
+	"\adynamic\x18\x02 \x01(\v2\x16.system.v1.DynamicInfoR\adynamic\"\xd1\x02\n" +
+	"\n" +
+	"StaticInfo\x12\x0e\n" +
+	"\x02os\x18\x01 \x01(\tR\x02os\x12\x1d\n" +
+	"\n" +
+	"os_release\x18\x02 \x01(\tR\tosRelease\x12\x1a\n" +
+	"\bhostname\x18\x03 \x01(\tR\bhostname\x12\x10\n" +
+	"\x03cpu\x18\x04 \x01(\tR\x03cpu\x12\x17\n" +
+	"\anum_cpu\x18\x05 \x01(\x05R\x06numCpu\x12\x12\n" +
+	"\x04arch\x18\x06 \x01(\tR\x04arch\x12\x16\n" +
+	"\x06memory\x18\a \x01(\x04R\x06memory\x12)\n" +
+	"\x10storage_capacity\x18\b \x01(\x04R\x0fstorageCapacity\x12\x1f\n" +
+	"\vhas_battery\x18\t \x01(\bR\n" +
+	"hasBattery\x12\x18\n" +
+	"\abattery\x18\n" +
+	" \x01(\tR\abattery\x12;\n" +
+	"\rpower_actions\x18\v \x03(\x0e2\x16.system.v1.PowerActionR\fpowerActions\"\xf6\x02\n" +
+	"\vDynamicInfo\x12\x1b\n" +
+	"\tcpu_usage\x18\x01 \x01(\x01R\bcpuUsage\x12\x19\n" +
+	"\bcpu_temp\x18\x02 \x01(\x01R\acpuTemp\x12\x1f\n" +
+	"\vmemory_used\x18\x03 \x01(\x04R\n" +
+	"memoryUsed\x12!\n" +
+	"\fstorage_used\x18\x04 \x01(\x04R\vstorageUsed\x12!\n" +
+	"\fbattery_temp\x18\x05 \x01(\x01R\vbatteryTemp\x12'\n" +
+	"\x0fbattery_percent\x18\x06 \x01(\x01R\x0ebatteryPercent\x12%\n" +
+	"\x0ebattery_status\x18\a \x01(\tR\rbatteryStatus\x120\n" +
+	"\tprocesses\x18\b \x03(\v2\x12.system.v1.ProcessR\tprocesses\x12.\n" +
+	"\bservices\x18\t \x03(\v2\x12.system.v1.ServiceR\bservices\x12\x16\n" +
+	"\x06uptime\x18\n" +
+	" \x01(\x04R\x06uptime\"\xce\x03\n" +
+	"\aProcess\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x18\n" +
+	"\athreads\x18\x04 \x01(\x05R\athreads\x12\x1f\n" +
+	"\vcpu_percent\x18\x05 \x01(\x01R\n" +
+	"cpuPercent\x12%\n" +
+	"\x0ememory_percent\x18\x06 \x01(\x02R\rmemoryPercent\x12\x1d\n" +
+	"\n" +
+	"parent_pid\x18\a \x01(\x05R\tparentPid\x12\x17\n" +
+	"\anum_fds\x18\b \x01(\x05R\x06numFds\x12#\n" +
+	"\rchildren_pids\x18\t \x03(\x05R\fchildrenPids\x12$\n" +
+	"\x0edisk_read_rate\x18\n" +
+	" \x01(\x01R\fdiskReadRate\x12&\n" +
+	"\x0fdisk_write_rate\x18\v \x01(\x01R\rdiskWriteRate\x129\n" +
+	"\x19voluntary_ctx_switch_rate\x18\f \x01(\x01R\x16voluntaryCtxSwitchRate\x12=\n" +
+	"\x1binvoluntary_ctx_switch_rate\x18\r \x01(\x01R\x18involuntaryCtxSwitchRate\"W\n" +
+	"\aService\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"\x10\n" +
+	"\x0eGetInfoRequest\"\x98\x01\n" +
+	"\x13WatchMetricsRequest\x12)\n" +
+	"\x10interval_seconds\x18\x01 \x01(\rR\x0fintervalSeconds\x12+\n" +
+	"\x11exclude_processes\x18\x02 \x01(\bR\x10excludeProcesses\x12)\n" +
+	"\x10exclude_services\x18\x03 \x01(\bR\x0fexcludeServices\"%\n" +
+	"\x11GetProcessRequest\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\"S\n" +
+	"\x14SignalProcessRequest\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12)\n" +
+	"\x06signal\x18\x02 \x01(\x0e2\x11.system.v1.SignalR\x06signal\"\x17\n" +
+	"\x15SignalProcessResponse\"'\n" +
+	"\x11GetServiceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"]\n" +
+	"\x15ControlServiceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x120\n" +
+	"\x06action\x18\x02 \x01(\x0e2\x18.system.v1.ServiceActionR\x06action\"\x18\n" +
+	"\x16ControlServiceResponse\"\xc4\x01\n" +
+	"\n" +
+	"LogOptions\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12$\n" +
+	"\x0ethis_boot_only\x18\x03 \x01(\bR\fthisBootOnly\x12\x14\n" +
+	"\x05lines\x18\x04 \x01(\x05R\x05lines\x12\x16\n" +
+	"\x06follow\x18\x05 \x01(\bR\x06follow\"G\n" +
+	"\x14GetSystemLogsRequest\x12/\n" +
+	"\aoptions\x18\x01 \x01(\v2\x15.system.v1.LogOptionsR\aoptions\"\\\n" +
+	"\x15GetServiceLogsRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12/\n" +
+	"\aoptions\x18\x02 \x01(\v2\x15.system.v1.LogOptionsR\aoptions\"\x1e\n" +
+	"\bLogChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"G\n" +
+	"\x15RunPowerActionRequest\x12.\n" +
+	"\x06action\x18\x01 \x01(\x0e2\x16.system.v1.PowerActionR\x06action\"\x18\n" +
+	"\x16RunPowerActionResponse\"\x92\x01\n" +
+	"\x1aSchedulePowerActionRequest\x12.\n" +
+	"\x06action\x18\x01 \x01(\x0e2\x16.system.v1.PowerActionR\x06action\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xd7\x01\n" +
+	"\x14ScheduledPowerAction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x06action\x18\x02 \x01(\x0e2\x16.system.v1.PowerActionR\x06action\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\"\n" +
+	" ListScheduledPowerActionsRequest\"^\n" +
+	"!ListScheduledPowerActionsResponse\x129\n" +
+	"\aactions\x18\x01 \x03(\v2\x1f.system.v1.ScheduledPowerActionR\aactions\"3\n" +
+	"!CancelScheduledPowerActionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"$\n" +
+	"\"CancelScheduledPowerActionResponse*\x84\x01\n" +
+	"\x06Signal\x12\x16\n" +
+	"\x12SIGNAL_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSIGNAL_SIGKILL\x10\x01\x12\x12\n" +
+	"\x0eSIGNAL_SIGTERM\x10\x02\x12\x12\n" +
+	"\x0eSIGNAL_SIGSTOP\x10\x03\x12\x12\n" +
+	"\x0eSIGNAL_SIGCONT\x10\x04\x12\x12\n" +
+	"\x0eSIGNAL_SIGQUIT\x10\x05*~\n" +
+	"\rServiceAction\x12\x1e\n" +
+	"\x1aSERVICE_ACTION_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14SERVICE_ACTION_START\x10\x01\x12\x17\n" +
+	"\x13SERVICE_ACTION_STOP\x10\x02\x12\x1a\n" +
+	"\x16SERVICE_ACTION_RESTART\x10\x03*\xdd\x01\n" +
+	"\vPowerAction\x12\x1c\n" +
+	"\x18POWER_ACTION_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15POWER_ACTION_SHUTDOWN\x10\x01\x12\x17\n" +
+	"\x13POWER_ACTION_REBOOT\x10\x02\x12\x18\n" +
+	"\x14POWER_ACTION_SUSPEND\x10\x03\x12\x1a\n" +
+	"\x16POWER_ACTION_HIBERNATE\x10\x04\x12\x1d\n" +
+	"\x19POWER_ACTION_HYBRID_SLEEP\x10\x05\x12'\n" +
+	"#POWER_ACTION_SUSPEND_THEN_HIBERNATE\x10\x062\xfe\a\n" +
+	"\rSystemService\x12;\n" +
+	"\aGetInfo\x12\x19.system.v1.GetInfoRequest\x1a\x15.system.v1.SystemInfo\x12H\n" +
+	"\fWatchMetrics\x12\x1e.system.v1.WatchMetricsRequest\x1a\x16.system.v1.DynamicInfo0\x01\x12>\n" +
+	"\n" +
+	"GetProcess\x12\x1c.system.v1.GetProcessRequest\x1a\x12.system.v1.Process\x12R\n" +
+	"\rSignalProcess\x12\x1f.system.v1.SignalProcessRequest\x1a .system.v1.SignalProcessResponse\x12>\n" +
+	"\n" +
+	"GetService\x12\x1c.system.v1.GetServiceRequest\x1a\x12.system.v1.Service\x12U\n" +
+	"\x0eControlService\x12 .system.v1.ControlServiceRequest\x1a!.system.v1.ControlServiceResponse\x12G\n" +
+	"\rGetSystemLogs\x12\x1f.system.v1.GetSystemLogsRequest\x1a\x13.system.v1.LogChunk0\x01\x12I\n" +
+	"\x0eGetServiceLogs\x12 .system.v1.GetServiceLogsRequest\x1a\x13.system.v1.LogChunk0\x01\x12U\n" +
+	"\x0eRunPowerAction\x12 .system.v1.RunPowerActionRequest\x1a!.system.v1.RunPowerActionResponse\x12]\n" +
+	"\x13SchedulePowerAction\x12%.system.v1.SchedulePowerActionRequest\x1a\x1f.system.v1.ScheduledPowerAction\x12v\n" +
+	"\x19ListScheduledPowerActions\x12+.system.v1.ListScheduledPowerActionsRequest\x1a,.system.v1.ListScheduledPowerActionsResponse\x12y\n" +
+	"\x1aCancelScheduledPowerAction\x12,.system.v1.CancelScheduledPowerActionRequest\x1a-.system.v1.CancelScheduledPowerActionResponseB*Z(github.com/tiredkangaroo/system/systempbb\x06proto3"
+
+var (
+	file_system_proto_rawDescOnce sync.Once
+	file_system_proto_rawDescData []byte
+)
+
+func file_system_proto_rawDescGZIP() []byte {
+	file_system_proto_rawDescOnce.Do(func() {
+		file_system_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_system_proto_rawDesc), len(file_system_proto_rawDesc)))
+	})
+	return file_system_proto_rawDescData
+}
+
+var file_system_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_system_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_system_proto_goTypes = []any{
+	(Signal)(0),                                // 0: system.v1.Signal
+	(ServiceAction)(0),                         // 1: system.v1.ServiceAction
+	(PowerAction)(0),                           // 2: system.v1.PowerAction
+	(*SystemInfo)(nil),                         // 3: system.v1.SystemInfo
+	(*StaticInfo)(nil),                         // 4: system.v1.StaticInfo
+	(*DynamicInfo)(nil),                        // 5: system.v1.DynamicInfo
+	(*Process)(nil),                            // 6: system.v1.Process
+	(*Service)(nil),                            // 7: system.v1.Service
+	(*GetInfoRequest)(nil),                     // 8: system.v1.GetInfoRequest
+	(*WatchMetricsRequest)(nil),                // 9: system.v1.WatchMetricsRequest
+	(*GetProcessRequest)(nil),                  // 10: system.v1.GetProcessRequest
+	(*SignalProcessRequest)(nil),               // 11: system.v1.SignalProcessRequest
+	(*SignalProcessResponse)(nil),              // 12: system.v1.SignalProcessResponse
+	(*GetServiceRequest)(nil),                  // 13: system.v1.GetServiceRequest
+	(*ControlServiceRequest)(nil),              // 14: system.v1.ControlServiceRequest
+	(*ControlServiceResponse)(nil),             // 15: system.v1.ControlServiceResponse
+	(*LogOptions)(nil),                         // 16: system.v1.LogOptions
+	(*GetSystemLogsRequest)(nil),               // 17: system.v1.GetSystemLogsRequest
+	(*GetServiceLogsRequest)(nil),              // 18: system.v1.GetServiceLogsRequest
+	(*LogChunk)(nil),                           // 19: system.v1.LogChunk
+	(*RunPowerActionRequest)(nil),              // 20: system.v1.RunPowerActionRequest
+	(*RunPowerActionResponse)(nil),             // 21: system.v1.RunPowerActionResponse
+	(*SchedulePowerActionRequest)(nil),         // 22: system.v1.SchedulePowerActionRequest
+	(*ScheduledPowerAction)(nil),               // 23: system.v1.ScheduledPowerAction
+	(*ListScheduledPowerActionsRequest)(nil),   // 24: system.v1.ListScheduledPowerActionsRequest
+	(*ListScheduledPowerActionsResponse)(nil),  // 25: system.v1.ListScheduledPowerActionsResponse
+	(*CancelScheduledPowerActionRequest)(nil),  // 26: system.v1.CancelScheduledPowerActionRequest
+	(*CancelScheduledPowerActionResponse)(nil), // 27: system.v1.CancelScheduledPowerActionResponse
+	(*timestamppb.Timestamp)(nil),              // 28: google.protobuf.Timestamp
+}
+var file_system_proto_depIdxs = []int32{
+	4,  // 0: system.v1.SystemInfo.static:type_name -> system.v1.StaticInfo
+	5,  // 1: system.v1.SystemInfo.dynamic:type_name -> system.v1.DynamicInfo
+	2,  // 2: system.v1.StaticInfo.power_actions:type_name -> system.v1.PowerAction
+	6,  // 3: system.v1.DynamicInfo.processes:type_name -> system.v1.Process
+	7,  // 4: system.v1.DynamicInfo.services:type_name -> system.v1.Service
+	0,  // 5: system.v1.SignalProcessRequest.signal:type_name -> system.v1.Signal
+	1,  // 6: system.v1.ControlServiceRequest.action:type_name -> system.v1.ServiceAction
+	28, // 7: system.v1.LogOptions.since:type_name -> google.protobuf.Timestamp
+	28, // 8: system.v1.LogOptions.until:type_name -> google.protobuf.Timestamp
+	16, // 9: system.v1.GetSystemLogsRequest.options:type_name -> system.v1.LogOptions
+	16, // 10: system.v1.GetServiceLogsRequest.options:type_name -> system.v1.LogOptions
+	2,  // 11: system.v1.RunPowerActionRequest.action:type_name -> system.v1.PowerAction
+	2,  // 12: system.v1.SchedulePowerActionRequest.action:type_name -> system.v1.PowerAction
+	28, // 13: system.v1.SchedulePowerActionRequest.at:type_name -> google.protobuf.Timestamp
+	2,  // 14: system.v1.ScheduledPowerAction.action:type_name -> system.v1.PowerAction
+	28, // 15: system.v1.ScheduledPowerAction.at:type_name -> google.protobuf.Timestamp
+	28, // 16: system.v1.ScheduledPowerAction.created_at:type_name -> google.protobuf.Timestamp
+	23, // 17: system.v1.ListScheduledPowerActionsResponse.actions:type_name -> system.v1.ScheduledPowerAction
+	8,  // 18: system.v1.SystemService.GetInfo:input_type -> system.v1.GetInfoRequest
+	9,  // 19: system.v1.SystemService.WatchMetrics:input_type -> system.v1.WatchMetricsRequest
+	10, // 20: system.v1.SystemService.GetProcess:input_type -> system.v1.GetProcessRequest
+	11, // 21: system.v1.SystemService.SignalProcess:input_type -> system.v1.SignalProcessRequest
+	13, // 22: system.v1.SystemService.GetService:input_type -> system.v1.GetServiceRequest
+	14, // 23: system.v1.SystemService.ControlService:input_type -> system.v1.ControlServiceRequest
+	17, // 24: system.v1.SystemService.GetSystemLogs:input_type -> system.v1.GetSystemLogsRequest
+	18, // 25: system.v1.SystemService.GetServiceLogs:input_type -> system.v1.GetServiceLogsRequest
+	20, // 26: system.v1.SystemService.RunPowerAction:input_type -> system.v1.RunPowerActionRequest
+	22, // 27: system.v1.SystemService.SchedulePowerAction:input_type -> system.v1.SchedulePowerActionRequest
+	24, // 28: system.v1.SystemService.ListScheduledPowerActions:input_type -> system.v1.ListScheduledPowerActionsRequest
+	26, // 29: system.v1.SystemService.CancelScheduledPowerAction:input_type -> system.v1.CancelScheduledPowerActionRequest
+	3,  // 30: system.v1.SystemService.GetInfo:output_type -> system.v1.SystemInfo
+	5,  // 31: system.v1.SystemService.WatchMetrics:output_type -> system.v1.DynamicInfo
+	6,  // 32: system.v1.SystemService.GetProcess:output_type -> system.v1.Process
+	12, // 33: system.v1.SystemService.SignalProcess:output_type -> system.v1.SignalProcessResponse
+	7,  // 34: system.v1.SystemService.GetService:output_type -> system.v1.Service
+	15, // 35: system.v1.SystemService.ControlService:output_type -> system.v1.ControlServiceResponse
+	19, // 36: system.v1.SystemService.GetSystemLogs:output_type -> system.v1.LogChunk
+	19, // 37: system.v1.SystemService.GetServiceLogs:output_type -> system.v1.LogChunk
+	21, // 38: system.v1.SystemService.RunPowerAction:output_type -> system.v1.RunPowerActionResponse
+	23, // 39: system.v1.SystemService.SchedulePowerAction:output_type -> system.v1.ScheduledPowerAction
+	25, // 40: system.v1.SystemService.ListScheduledPowerActions:output_type -> system.v1.ListScheduledPowerActionsResponse
+	27, // 41: system.v1.SystemService.CancelScheduledPowerAction:output_type -> system.v1.CancelScheduledPowerActionResponse
+	30, // [30:42] is the sub-list for method output_type
+	18, // [18:30] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_system_proto_init() }
+func file_system_proto_init() {
+	if File_system_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_system_proto_rawDesc), len(file_system_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   25,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_system_proto_goTypes,
+		DependencyIndexes: file_system_proto_depIdxs,
+		EnumInfos:         file_system_proto_enumTypes,
+		MessageInfos:      file_system_proto_msgTypes,
+	}.Build()
+	File_system_proto = out.File
+	file_system_proto_goTypes = nil
+	file_system_proto_depIdxs = nil
+}
@@ -0,0 +1,570 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: system.proto
+
+// the gRPC api of system, mirroring the REST api under /api/v1. authenticate with an api
+// token in the "authorization" metadata ("Bearer sys_...") or a client certificate.
+
+package systempb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SystemService_GetInfo_FullMethodName                    = "/system.v1.SystemService/GetInfo"
+	SystemService_WatchMetrics_FullMethodName               = "/system.v1.SystemService/WatchMetrics"
+	SystemService_GetProcess_FullMethodName                 = "/system.v1.SystemService/GetProcess"
+	SystemService_SignalProcess_FullMethodName              = "/system.v1.SystemService/SignalProcess"
+	SystemService_GetService_FullMethodName                 = "/system.v1.SystemService/GetService"
+	SystemService_ControlService_FullMethodName             = "/system.v1.SystemService/ControlService"
+	SystemService_GetSystemLogs_FullMethodName              = "/system.v1.SystemService/GetSystemLogs"
+	SystemService_GetServiceLogs_FullMethodName             = "/system.v1.SystemService/GetServiceLogs"
+	SystemService_RunPowerAction_FullMethodName             = "/system.v1.SystemService/RunPowerAction"
+	SystemService_SchedulePowerAction_FullMethodName        = "/system.v1.SystemService/SchedulePowerAction"
+	SystemService_ListScheduledPowerActions_FullMethodName  = "/system.v1.SystemService/ListScheduledPowerActions"
+	SystemService_CancelScheduledPowerAction_FullMethodName = "/system.v1.SystemService/CancelScheduledPowerAction"
+)
+
+// SystemServiceClient is the client API for SystemService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SystemServiceClient interface {
+	// system information. requires read_metrics.
+	GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*SystemInfo, error)
+	// streams metrics every interval (the server's websocket interval by default). requires read_metrics.
+	WatchMetrics(ctx context.Context, in *WatchMetricsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DynamicInfo], error)
+	// requires read_metrics.
+	GetProcess(ctx context.Context, in *GetProcessRequest, opts ...grpc.CallOption) (*Process, error)
+	// requires signal_processes.
+	SignalProcess(ctx context.Context, in *SignalProcessRequest, opts ...grpc.CallOption) (*SignalProcessResponse, error)
+	// requires read_metrics.
+	GetService(ctx context.Context, in *GetServiceRequest, opts ...grpc.CallOption) (*Service, error)
+	// starts, stops or restarts a service. requires manage_services.
+	ControlService(ctx context.Context, in *ControlServiceRequest, opts ...grpc.CallOption) (*ControlServiceResponse, error)
+	// stream the system or service logs in chunks. requires read_logs.
+	GetSystemLogs(ctx context.Context, in *GetSystemLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error)
+	GetServiceLogs(ctx context.Context, in *GetServiceLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error)
+	// power actions, now or scheduled. require power_actions, except listing scheduled ones
+	// which requires read_metrics.
+	RunPowerAction(ctx context.Context, in *RunPowerActionRequest, opts ...grpc.CallOption) (*RunPowerActionResponse, error)
+	SchedulePowerAction(ctx context.Context, in *SchedulePowerActionRequest, opts ...grpc.CallOption) (*ScheduledPowerAction, error)
+	ListScheduledPowerActions(ctx context.Context, in *ListScheduledPowerActionsRequest, opts ...grpc.CallOption) (*ListScheduledPowerActionsResponse, error)
+	CancelScheduledPowerAction(ctx context.Context, in *CancelScheduledPowerActionRequest, opts ...grpc.CallOption) (*CancelScheduledPowerActionResponse, error)
+}
+
+type systemServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSystemServiceClient(cc grpc.ClientConnInterface) SystemServiceClient {
+	return &systemServiceClient{cc}
+}
+
+func (c *systemServiceClient) GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*SystemInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SystemInfo)
+	err := c.cc.Invoke(ctx, SystemService_GetInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) WatchMetrics(ctx context.Context, in *WatchMetricsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DynamicInfo], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SystemService_ServiceDesc.Streams[0], SystemService_WatchMetrics_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchMetricsRequest, DynamicInfo]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SystemService_WatchMetricsClient = grpc.ServerStreamingClient[DynamicInfo]
+
+func (c *systemServiceClient) GetProcess(ctx context.Context, in *GetProcessRequest, opts ...grpc.CallOption) (*Process, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Process)
+	err := c.cc.Invoke(ctx, SystemService_GetProcess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) SignalProcess(ctx context.Context, in *SignalProcessRequest, opts ...grpc.CallOption) (*SignalProcessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SignalProcessResponse)
+	err := c.cc.Invoke(ctx, SystemService_SignalProcess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) GetService(ctx context.Context, in *GetServiceRequest, opts ...grpc.CallOption) (*Service, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Service)
+	err := c.cc.Invoke(ctx, SystemService_GetService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) ControlService(ctx context.Context, in *ControlServiceRequest, opts ...grpc.CallOption) (*ControlServiceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ControlServiceResponse)
+	err := c.cc.Invoke(ctx, SystemService_ControlService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) GetSystemLogs(ctx context.Context, in *GetSystemLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SystemService_ServiceDesc.Streams[1], SystemService_GetSystemLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetSystemLogsRequest, LogChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SystemService_GetSystemLogsClient = grpc.ServerStreamingClient[LogChunk]
+
+func (c *systemServiceClient) GetServiceLogs(ctx context.Context, in *GetServiceLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SystemService_ServiceDesc.Streams[2], SystemService_GetServiceLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetServiceLogsRequest, LogChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SystemService_GetServiceLogsClient = grpc.ServerStreamingClient[LogChunk]
+
+func (c *systemServiceClient) RunPowerAction(ctx context.Context, in *RunPowerActionRequest, opts ...grpc.CallOption) (*RunPowerActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunPowerActionResponse)
+	err := c.cc.Invoke(ctx, SystemService_RunPowerAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) SchedulePowerAction(ctx context.Context, in *SchedulePowerActionRequest, opts ...grpc.CallOption) (*ScheduledPowerAction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScheduledPowerAction)
+	err := c.cc.Invoke(ctx, SystemService_SchedulePowerAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) ListScheduledPowerActions(ctx context.Context, in *ListScheduledPowerActionsRequest, opts ...grpc.CallOption) (*ListScheduledPowerActionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListScheduledPowerActionsResponse)
+	err := c.cc.Invoke(ctx, SystemService_ListScheduledPowerActions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemServiceClient) CancelScheduledPowerAction(ctx context.Context, in *CancelScheduledPowerActionRequest, opts ...grpc.CallOption) (*CancelScheduledPowerActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelScheduledPowerActionResponse)
+	err := c.cc.Invoke(ctx, SystemService_CancelScheduledPowerAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SystemServiceServer is the server API for SystemService service.
+// All implementations must embed UnimplementedSystemServiceServer
+// for forward compatibility.
+type SystemServiceServer interface {
+	// system information. requires read_metrics.
+	GetInfo(context.Context, *GetInfoRequest) (*SystemInfo, error)
+	// streams metrics every interval (the server's websocket interval by default). requires read_metrics.
+	WatchMetrics(*WatchMetricsRequest, grpc.ServerStreamingServer[DynamicInfo]) error
+	// requires read_metrics.
+	GetProcess(context.Context, *GetProcessRequest) (*Process, error)
+	// requires signal_processes.
+	SignalProcess(context.Context, *SignalProcessRequest) (*SignalProcessResponse, error)
+	// requires read_metrics.
+	GetService(context.Context, *GetServiceRequest) (*Service, error)
+	// starts, stops or restarts a service. requires manage_services.
+	ControlService(context.Context, *ControlServiceRequest) (*ControlServiceResponse, error)
+	// stream the system or service logs in chunks. requires read_logs.
+	GetSystemLogs(*GetSystemLogsRequest, grpc.ServerStreamingServer[LogChunk]) error
+	GetServiceLogs(*GetServiceLogsRequest, grpc.ServerStreamingServer[LogChunk]) error
+	// power actions, now or scheduled. require power_actions, except listing scheduled ones
+	// which requires read_metrics.
+	RunPowerAction(context.Context, *RunPowerActionRequest) (*RunPowerActionResponse, error)
+	SchedulePowerAction(context.Context, *SchedulePowerActionRequest) (*ScheduledPowerAction, error)
+	ListScheduledPowerActions(context.Context, *ListScheduledPowerActionsRequest) (*ListScheduledPowerActionsResponse, error)
+	CancelScheduledPowerAction(context.Context, *CancelScheduledPowerActionRequest) (*CancelScheduledPowerActionResponse, error)
+	mustEmbedUnimplementedSystemServiceServer()
+}
+
+// UnimplementedSystemServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSystemServiceServer struct{}
+
+func (UnimplementedSystemServiceServer) GetInfo(context.Context, *GetInfoRequest) (*SystemInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedSystemServiceServer) WatchMetrics(*WatchMetricsRequest, grpc.ServerStreamingServer[DynamicInfo]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchMetrics not implemented")
+}
+func (UnimplementedSystemServiceServer) GetProcess(context.Context, *GetProcessRequest) (*Process, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProcess not implemented")
+}
+func (UnimplementedSystemServiceServer) SignalProcess(context.Context, *SignalProcessRequest) (*SignalProcessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignalProcess not implemented")
+}
+func (UnimplementedSystemServiceServer) GetService(context.Context, *GetServiceRequest) (*Service, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetService not implemented")
+}
+func (UnimplementedSystemServiceServer) ControlService(context.Context, *ControlServiceRequest) (*ControlServiceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ControlService not implemented")
+}
+func (UnimplementedSystemServiceServer) GetSystemLogs(*GetSystemLogsRequest, grpc.ServerStreamingServer[LogChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetSystemLogs not implemented")
+}
+func (UnimplementedSystemServiceServer) GetServiceLogs(*GetServiceLogsRequest, grpc.ServerStreamingServer[LogChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetServiceLogs not implemented")
+}
+func (UnimplementedSystemServiceServer) RunPowerAction(context.Context, *RunPowerActionRequest) (*RunPowerActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunPowerAction not implemented")
+}
+func (UnimplementedSystemServiceServer) SchedulePowerAction(context.Context, *SchedulePowerActionRequest) (*ScheduledPowerAction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SchedulePowerAction not implemented")
+}
+func (UnimplementedSystemServiceServer) ListScheduledPowerActions(context.Context, *ListScheduledPowerActionsRequest) (*ListScheduledPowerActionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListScheduledPowerActions not implemented")
+}
+func (UnimplementedSystemServiceServer) CancelScheduledPowerAction(context.Context, *CancelScheduledPowerActionRequest) (*CancelScheduledPowerActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduledPowerAction not implemented")
+}
+func (UnimplementedSystemServiceServer) mustEmbedUnimplementedSystemServiceServer() {}
+func (UnimplementedSystemServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeSystemServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SystemServiceServer will
+// result in compilation errors.
+type UnsafeSystemServiceServer interface {
+	mustEmbedUnimplementedSystemServiceServer()
+}
+
+func RegisterSystemServiceServer(s grpc.ServiceRegistrar, srv SystemServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSystemServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SystemService_ServiceDesc, srv)
+}
+
+func _SystemService_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_GetInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).GetInfo(ctx, req.(*GetInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_WatchMetrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchMetricsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SystemServiceServer).WatchMetrics(m, &grpc.GenericServerStream[WatchMetricsRequest, DynamicInfo]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SystemService_WatchMetricsServer = grpc.ServerStreamingServer[DynamicInfo]
+
+func _SystemService_GetProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).GetProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_GetProcess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).GetProcess(ctx, req.(*GetProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_SignalProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignalProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).SignalProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_SignalProcess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).SignalProcess(ctx, req.(*SignalProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_GetService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).GetService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_GetService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).GetService(ctx, req.(*GetServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_ControlService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ControlServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).ControlService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_ControlService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).ControlService(ctx, req.(*ControlServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_GetSystemLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetSystemLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SystemServiceServer).GetSystemLogs(m, &grpc.GenericServerStream[GetSystemLogsRequest, LogChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SystemService_GetSystemLogsServer = grpc.ServerStreamingServer[LogChunk]
+
+func _SystemService_GetServiceLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetServiceLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SystemServiceServer).GetServiceLogs(m, &grpc.GenericServerStream[GetServiceLogsRequest, LogChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SystemService_GetServiceLogsServer = grpc.ServerStreamingServer[LogChunk]
+
+func _SystemService_RunPowerAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunPowerActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).RunPowerAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_RunPowerAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).RunPowerAction(ctx, req.(*RunPowerActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_SchedulePowerAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SchedulePowerActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).SchedulePowerAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_SchedulePowerAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).SchedulePowerAction(ctx, req.(*SchedulePowerActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_ListScheduledPowerActions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListScheduledPowerActionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).ListScheduledPowerActions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_ListScheduledPowerActions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).ListScheduledPowerActions(ctx, req.(*ListScheduledPowerActionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemService_CancelScheduledPowerAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelScheduledPowerActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServiceServer).CancelScheduledPowerAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemService_CancelScheduledPowerAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServiceServer).CancelScheduledPowerAction(ctx, req.(*CancelScheduledPowerActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SystemService_ServiceDesc is the grpc.ServiceDesc for SystemService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SystemService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "system.v1.SystemService",
+	HandlerType: (*SystemServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetInfo",
+			Handler:    _SystemService_GetInfo_Handler,
+		},
+		{
+			MethodName: "GetProcess",
+			Handler:    _SystemService_GetProcess_Handler,
+		},
+		{
+			MethodName: "SignalProcess",
+			Handler:    _SystemService_SignalProcess_Handler,
+		},
+		{
+			MethodName: "GetService",
+			Handler:    _SystemService_GetService_Handler,
+		},
+		{
+			MethodName: "ControlService",
+			Handler:    _SystemService_ControlService_Handler,
+		},
+		{
+			MethodName: "RunPowerAction",
+			Handler:    _SystemService_RunPowerAction_Handler,
+		},
+		{
+			MethodName: "SchedulePowerAction",
+			Handler:    _SystemService_SchedulePowerAction_Handler,
+		},
+		{
+			MethodName: "ListScheduledPowerActions",
+			Handler:    _SystemService_ListScheduledPowerActions_Handler,
+		},
+		{
+			MethodName: "CancelScheduledPowerAction",
+			Handler:    _SystemService_CancelScheduledPowerAction_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchMetrics",
+			Handler:       _SystemService_WatchMetrics_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetSystemLogs",
+			Handler:       _SystemService_GetSystemLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetServiceLogs",
+			Handler:       _SystemService_GetServiceLogs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "system.proto",
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/certs"
+)
+
+// tlsInit returns the TLS configuration of the server, or nil if TLS is disabled. the
+// certificate is reloaded from disk when its files change, so renewing it doesn't need a
+// restart.
+func tlsInit() (*tls.Config, error) {
+	certFile, keyFile := conf().TLS.CertFile, conf().TLS.KeyFile
+	if conf().TLS.SelfSigned {
+		hosts := append(certs.LocalHosts(), conf().TLS.Hosts...)
+		selfSigned, err := certs.EnsureSelfSigned(filepath.Join(stateDir(), "tls"), hosts)
+		if err != nil {
+			return nil, err
+		}
+		if selfSigned.Generated {
+			slog.Info("generated self-signed tls certificate", "hosts", hosts)
+		}
+		slog.Info("using self-signed tls certificate, trust the CA in your browser or compare fingerprints",
+			"ca_file", selfSigned.CAFile,
+			"ca_sha256", certs.Fingerprint(selfSigned.CA),
+			"cert_sha256", certs.Fingerprint(selfSigned.Cert),
+		)
+		certFile, keyFile = selfSigned.CertFile, selfSigned.KeyFile
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, nil
+	}
+	reloader, err := certs.NewReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{GetCertificate: reloader.GetCertificate}
+	if caFile := conf().TLS.ClientCAFile; caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in client CA bundle %s", caFile)
+		}
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if conf().TLS.ClientAuth == "required" {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		slog.Info("client certificates enabled", "ca_file", caFile, "client_auth", tlsConfig.ClientAuth.String())
+	}
+	return tlsConfig, nil
+}
+
+// clientCertIdentity returns the user that the verified client certificate of the
+// connection maps to in tls.client_certs.
+func clientCertIdentity(c *fiber.Ctx) (string, auth.Role, bool) {
+	return certIdentity(c.Context().TLSConnectionState(), c.IP())
+}
+
+// certIdentity maps the verified client certificate of a connection to a user.
+func certIdentity(state *tls.ConnectionState, ip string) (string, auth.Role, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return "", "", false
+	}
+	cert := state.VerifiedChains[0][0]
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	for _, m := range conf().TLS.ClientCerts {
+		if !slices.Contains(names, m.Match) {
+			continue
+		}
+		if m.Role != "" {
+			return m.User, auth.Role(m.Role), true
+		}
+		user, err := lookupUser(m.User)
+		if err != nil {
+			securityLog.Warn("client certificate maps to a user that does not exist", "match", m.Match, "user", m.User)
+			return "", "", false
+		}
+		return user.Name, user.Role, true
+	}
+	securityLog.Warn("client certificate does not map to a user", "subject", cert.Subject.String(), "ip", ip)
+	return "", "", false
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/tiredkangaroo/system/auth"
+	"github.com/tiredkangaroo/system/hub"
+	"github.com/tiredkangaroo/system/system"
+)
+
+// the topics websocket (/api/v1/ws) sends only what a client subscribed to. every topic
+// starts with a snapshot and then sends what changed, at most once per interval:
+//
+//	-> {"type": "subscribe", "topic": "processes", "interval": 5}
+//	<- {"topic": "processes", "type": "snapshot", "data": [...]}
+//	<- {"topic": "processes", "type": "diff", "data": {"added": [...], "removed": [...], "changed": [...]}}
+//	-> {"type": "unsubscribe", "topic": "processes"}
+//
+// metrics diffs only contain the fields that changed, alerts are sent again as a whole
+// when they change, static info is sent once and logs are sent as "data" as they are
+// written (followed, starting with the last lines) until "end".
+
+// wsRequest is a message from the client.
+type wsRequest struct {
+	Type     string `json:"type"` // subscribe or unsubscribe
+	Topic    string `json:"topic"`
+	Interval int    `json:"interval"` // seconds between updates, refresh.websocket_interval if 0
+	Service  string `json:"service"`  // logs: the logs of a service instead of the system
+	Lines    int    `json:"lines"`    // logs: how many of the last lines to send first
+}
+
+// wsMessage is a message to the client.
+type wsMessage struct {
+	Topic string `json:"topic,omitempty"`
+	Type  string `json:"type"` // snapshot, diff, data, end or error
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// topicSource is where the topics get their data: this server or, through the hub, an
+// agent. the channels are closed when newer data is available, nil if it has to be polled.
+type topicSource interface {
+	static() (system.StaticInfo, error)
+	metrics() (system.Metrics, <-chan struct{}, error)
+	processes() ([]system.Process, <-chan struct{}, error)
+	services() ([]system.Service, <-chan struct{}, error)
+	alerts() ([]hub.Alert, <-chan struct{}, error)
+	logs(ctx context.Context, service string, lines int) (io.ReadCloser, error)
+	// watch keeps fields collected while a topic is subscribed to them.
+	watch(fields system.Fields) (stop func())
+}
+
+type wsSession struct {
+	conn     *websocket.Conn
+	src      topicSource
+	readLogs bool
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	subs    map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// topicsPermissions stores whether the client may subscribe to logs before the connection
+// is upgraded.
+func topicsPermissions(checkFeature bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("read_logs", can(c, auth.PermReadLogs) && (!checkFeature || conf().Features.Enabled("logs")))
+		return c.Next()
+	}
+}
+
+func topicsWebsocket(source func(c *websocket.Conn) topicSource) func(*websocket.Conn) {
+	return func(c *websocket.Conn) {
+		readLogs, _ := c.Locals("read_logs").(bool)
+		s := &wsSession{conn: c, src: source(c), readLogs: readLogs, subs: make(map[string]context.CancelFunc)}
+		ctx, cancel := context.WithCancel(shuttingDown)
+		closed := make(chan struct{})
+		stop := context.AfterFunc(shuttingDown, func() {
+			defer close(closed)
+			s.writeMu.Lock()
+			c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"), time.Now().Add(time.Second))
+			s.writeMu.Unlock()
+			c.Close()
+		})
+		defer func() {
+			if !stop() {
+				<-closed // it's closing the connection
+			}
+			cancel()
+			s.wg.Wait() // the connection is reused by fiber once the handler returns
+			c.Close()
+		}()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req wsRequest
+			if err := json.Unmarshal(msg, &req); err != nil {
+				s.send(wsMessage{Type: "error", Error: "invalid message: " + err.Error()})
+				continue
+			}
+			s.handle(ctx, req)
+		}
+	}
+}
+
+func (s *wsSession) send(msg wsMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteJSON(msg); err != nil {
+		slog.Debug("websocket write json", "error", err)
+	}
+}
+
+func (s *wsSession) sendError(topic string, err error) {
+	s.send(wsMessage{Topic: topic, Type: "error", Error: err.Error()})
+}
+
+func (s *wsSession) handle(ctx context.Context, req wsRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.subs[req.Topic]; ok {
+		cancel()
+		delete(s.subs, req.Topic)
+	}
+	switch req.Type {
+	case "unsubscribe":
+		return
+	case "subscribe":
+	default:
+		s.sendError(req.Topic, fmt.Errorf("unknown message type %q, use subscribe or unsubscribe", req.Type))
+		return
+	}
+	interval := conf().Refresh.WebsocketInterval.D()
+	if req.Interval > 0 {
+		interval = time.Duration(req.Interval) * time.Second
+	}
+	var run func(ctx context.Context)
+	switch req.Topic {
+	case "static":
+		run = func(ctx context.Context) {
+			info, err := s.src.static()
+			if err != nil {
+				s.sendError("static", err)
+				return
+			}
+			s.send(wsMessage{Topic: "static", Type: "snapshot", Data: info})
+		}
+	case "metrics":
+		run = func(ctx context.Context) {
+			defer s.src.watch(system.MetricsFields)()
+			pollTopic(ctx, s, "metrics", interval, func() (map[string]any, <-chan struct{}, error) {
+				m, next, err := s.src.metrics()
+				return m.Map(), next, err
+			}, func(old, cur map[string]any) (any, bool) {
+				d := system.DiffMetrics(old, cur)
+				return d, len(d) > 0
+			})
+		}
+	case "processes":
+		run = func(ctx context.Context) {
+			defer s.src.watch(system.FieldProcesses)()
+			pollTopic(ctx, s, "processes", interval, s.src.processes, func(old, cur []system.Process) (any, bool) {
+				d := system.DiffProcesses(old, cur)
+				return d, !d.Empty()
+			})
+		}
+	case "services":
+		run = func(ctx context.Context) {
+			defer s.src.watch(system.FieldServices)()
+			pollTopic(ctx, s, "services", interval, s.src.services, func(old, cur []system.Service) (any, bool) {
+				d := system.DiffServices(old, cur)
+				return d, !d.Empty()
+			})
+		}
+	case "alerts":
+		run = func(ctx context.Context) {
+			defer s.src.watch(alertFields)()
+			pollTopic(ctx, s, "alerts", interval, s.src.alerts, nil)
+		}
+	case "logs":
+		if !s.readLogs {
+			s.sendError("logs", errors.New("forbidden, logs are disabled or you lack the read_logs permission"))
+			return
+		}
+		run = func(ctx context.Context) {
+			s.streamLogs(ctx, req.Service, req.Lines)
+		}
+	default:
+		s.sendError(req.Topic, fmt.Errorf("unknown topic %q", req.Topic))
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.subs[req.Topic] = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		run(ctx)
+	}()
+}
+
+// pollTopic sends a snapshot of a topic, then what changed according to diff when the
+// source has newer data, at most every interval. if diff is nil, the snapshot is sent
+// again when it changes.
+func pollTopic[T any](ctx context.Context, s *wsSession, topic string, interval time.Duration, get func() (T, <-chan struct{}, error), diff func(old, cur T) (any, bool)) {
+	var last T
+	sent := false
+	for {
+		cur, next, err := get()
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			s.sendError(topic, err)
+		case !sent:
+			s.send(wsMessage{Topic: topic, Type: "snapshot", Data: cur})
+			last, sent = cur, true
+		case diff == nil:
+			if !reflect.DeepEqual(last, cur) {
+				s.send(wsMessage{Topic: topic, Type: "snapshot", Data: cur})
+				last = cur
+			}
+		default:
+			if d, changed := diff(last, cur); changed {
+				s.send(wsMessage{Topic: topic, Type: "diff", Data: d})
+				last = cur
+			}
+		}
+		if !waitForUpdate(ctx, next, interval) {
+			return
+		}
+	}
+}
+
+// waitForUpdate waits for interval and then for next to be closed, if not nil. it returns
+// false if ctx is done first.
+func waitForUpdate(ctx context.Context, next <-chan struct{}, interval time.Duration) bool {
+	t := time.NewTimer(interval)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+	}
+	if next == nil {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-next:
+		return true
+	}
+}
+
+func (s *wsSession) streamLogs(ctx context.Context, service string, lines int) {
+	reader, err := s.src.logs(ctx, service, lines)
+	if err != nil {
+		s.sendError("logs", err)
+		return
+	}
+	reader = trackStream(reader)
+	defer reader.Close()
+	stop := context.AfterFunc(ctx, func() { reader.Close() })
+	defer stop()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			s.send(wsMessage{Topic: "logs", Type: "data", Data: string(buf[:n])})
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				s.send(wsMessage{Topic: "logs", Type: "end"})
+			}
+			return
+		}
+	}
+}
+
+// localSource is the topic source of this server, which waits for the collectors of
+// infoService.
+type localSource struct {
+	sys         system.System
+	infoService *system.SystemInfoService
+}
+
+func (l localSource) static() (system.StaticInfo, error) {
+	return l.infoService.GetStaticInfo(shuttingDown)
+}
+
+func (l localSource) metrics() (system.Metrics, <-chan struct{}, error) {
+	return l.infoService.GetMetrics(shuttingDown, system.MetricsFields)
+}
+
+func (l localSource) processes() ([]system.Process, <-chan struct{}, error) {
+	return l.infoService.GetProcesses(shuttingDown)
+}
+
+func (l localSource) services() ([]system.Service, <-chan struct{}, error) {
+	return l.infoService.GetServices(shuttingDown)
+}
+
+// alertFields are the fields the alert thresholds are checked against.
+const alertFields = system.FieldCPUUsage | system.FieldMemoryUsed | system.FieldStorageUsed | system.FieldServices
+
+// alerts are checked against the same thresholds as the hub's, when metrics change.
+func (l localSource) alerts() ([]hub.Alert, <-chan struct{}, error) {
+	_, next, err := l.infoService.GetMetrics(shuttingDown, alertFields)
+	if err != nil {
+		return nil, next, err
+	}
+	info, err := l.infoService.GetSystemInfo(shuttingDown, alertFields)
+	if err != nil {
+		return nil, next, err
+	}
+	return hubOptions(conf().Hub).Thresholds.Check(info.Hostname, info), next, nil
+}
+
+func (l localSource) watch(fields system.Fields) func() {
+	return l.infoService.Watch(fields)
+}
+
+func (l localSource) logs(ctx context.Context, service string, lines int) (io.ReadCloser, error) {
+	opts := system.LogOptions{Lines: lines, Follow: true}
+	if service != "" {
+		return l.sys.GetServiceLog(service, opts)
+	}
+	return l.sys.GetSystemLogs(opts)
+}
+
+// agentSource is the topic source of an agent, fetched over its http api. the info is
+// shared by the topics of a connection for a second so they don't each fetch it.
+type agentSource struct {
+	name string
+
+	mu     sync.Mutex
+	last   *system.SystemInfo
+	lastAt time.Time
+}
+
+func (a *agentSource) info() (*system.SystemInfo, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.last != nil && time.Since(a.lastAt) < time.Second {
+		return a.last, nil
+	}
+	resp, err := a.get(shuttingDown, "/api/v1/info")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var info system.SystemInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	a.last, a.lastAt = &info, time.Now()
+	return a.last, nil
+}
+
+func (a *agentSource) static() (system.StaticInfo, error) {
+	info, err := a.info()
+	if err != nil {
+		return system.StaticInfo{}, err
+	}
+	return info.StaticInfo, nil
+}
+
+func (a *agentSource) metrics() (system.Metrics, <-chan struct{}, error) {
+	info, err := a.info()
+	if err != nil {
+		return system.Metrics{}, nil, err
+	}
+	return info.Metrics, nil, nil
+}
+
+func (a *agentSource) processes() ([]system.Process, <-chan struct{}, error) {
+	info, err := a.info()
+	if err != nil {
+		return nil, nil, err
+	}
+	return info.Processes, nil, nil
+}
+
+func (a *agentSource) services() ([]system.Service, <-chan struct{}, error) {
+	info, err := a.info()
+	if err != nil {
+		return nil, nil, err
+	}
+	return info.Services, nil, nil
+}
+
+// alerts are the ones of the last poll of the hub.
+func (a *agentSource) alerts() ([]hub.Alert, <-chan struct{}, error) {
+	alerts, err := fleet.HostAlerts(a.name)
+	return alerts, nil, err
+}
+
+// watch does nothing, the agent collects what its own clients request.
+func (a *agentSource) watch(system.Fields) func() { return func() {} }
+
+func (a *agentSource) logs(ctx context.Context, service string, lines int) (io.ReadCloser, error) {
+	path := "/api/v1/system/logs"
+	if service != "" {
+		path = "/api/v1/service/" + url.PathEscape(service) + "/logs"
+	}
+	resp, err := a.get(ctx, fmt.Sprintf("%s?follow=true&lines=%d", path, lines))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// get makes a request to the agent, returning the error of the agent if it fails.
+func (a *agentSource) get(ctx context.Context, path string) (*http.Response, error) {
+	resp, err := fleet.Do(ctx, a.name, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("agent is unreachable: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return nil, fmt.Errorf("agent returned %d: %s", resp.StatusCode, body.Error)
+	}
+	return resp, nil
+}
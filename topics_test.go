@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/tiredkangaroo/system/config"
+	"github.com/tiredkangaroo/system/fake"
+	"github.com/tiredkangaroo/system/hub"
+	"github.com/tiredkangaroo/system/system"
+)
+
+// dial opens a websocket to path. headers are pairs of names and values.
+func (s *testServer) dial(path string, headers ...string) *websocket.Conn {
+	s.t.Helper()
+	conn, resp, err := s.tryDial(path, headers...)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		s.t.Fatalf("dial %s: %v (status %d)", path, err, status)
+	}
+	s.t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func (s *testServer) tryDial(path string, headers ...string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	for i := 0; i+1 < len(headers); i += 2 {
+		header.Add(headers[i], headers[i+1])
+	}
+	return websocket.DefaultDialer.Dial("ws://"+s.listen()+path, header)
+}
+
+func send(t *testing.T, conn *websocket.Conn, req wsRequest) {
+	t.Helper()
+	if err := conn.WriteJSON(req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitMessage reads messages until one that matches, and fails the test if none does in a
+// few seconds.
+func waitMessage(t *testing.T, conn *websocket.Conn, what string, match func(msg wsMessage) bool) wsMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for %s: %v", what, err)
+		}
+		if match(msg) {
+			return msg
+		}
+	}
+}
+
+// is returns a match for waitMessage of the messages of topic of a type.
+func is(topic, typ string) func(msg wsMessage) bool {
+	return func(msg wsMessage) bool { return msg.Topic == topic && msg.Type == typ }
+}
+
+// as decodes the data of a message, which was decoded as any, into a T.
+func as[T any](t *testing.T, data any) T {
+	t.Helper()
+	var v T
+	b, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(b, &v)
+	}
+	if err != nil {
+		t.Fatalf("decode %v: %v", data, err)
+	}
+	return v
+}
+
+func TestInfoWebsocket(t *testing.T) {
+	s := newTestServer(t, nil)
+	conn := s.dial("/api/v1/info/ws")
+	var info system.SystemInfo
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Hostname != "test" || info.CPU_Usage != 12.5 || len(info.Processes) != 2 || len(info.Services) != 3 {
+		t.Fatalf("first info = %+v", info)
+	}
+	s.sys.PushMetrics(system.Metrics{CPU_Usage: 50})
+	for info.CPU_Usage != 50 {
+		if err := conn.ReadJSON(&info); err != nil {
+			t.Fatalf("waiting for the pushed metrics: %v", err)
+		}
+	}
+}
+
+func TestTopics(t *testing.T) {
+	s := newTestServer(t, nil)
+	conn := s.dial("/api/v1/ws")
+
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "static"})
+	msg := waitMessage(t, conn, "static info", is("static", "snapshot"))
+	if static := as[system.StaticInfo](t, msg.Data); static.Hostname != "test" {
+		t.Fatalf("static = %+v", static)
+	}
+
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "metrics"})
+	msg = waitMessage(t, conn, "a metrics snapshot", is("metrics", "snapshot"))
+	if m := as[map[string]any](t, msg.Data); m["cpu_usage"] != 12.5 || m["uptime"] != float64(3600) {
+		t.Fatalf("metrics snapshot = %v", m)
+	}
+	s.sys.PushMetrics(system.Metrics{CPU_Usage: 30, MemoryUsed: 2 << 30, StorageUsed: 40 << 30, Uptime: 3600})
+	msg = waitMessage(t, conn, "a metrics diff", is("metrics", "diff"))
+	if d := as[map[string]any](t, msg.Data); len(d) != 1 || d["cpu_usage"] != float64(30) {
+		t.Fatalf("metrics diff = %v, want only the cpu usage", d)
+	}
+	send(t, conn, wsRequest{Type: "unsubscribe", Topic: "metrics"})
+
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "processes"})
+	msg = waitMessage(t, conn, "a processes snapshot", is("processes", "snapshot"))
+	if p := as[[]system.Process](t, msg.Data); len(p) != 2 {
+		t.Fatalf("processes snapshot = %+v", p)
+	}
+	s.sys.PushProcesses([]system.Process{
+		{PID: 42, Name: "worker", Status: "running", Threads: 8, CPUPercent: 90},
+		{PID: 99, Name: "cron", Status: "sleep", Threads: 1},
+	})
+	msg = waitMessage(t, conn, "a processes diff", is("processes", "diff"))
+	d := as[system.ProcessDiff](t, msg.Data)
+	if len(d.Added) != 1 || d.Added[0].PID != 99 || len(d.Removed) != 1 || d.Removed[0] != 1 || len(d.Changed) != 1 || d.Changed[0].Threads != 8 {
+		t.Fatalf("processes diff = %+v", d)
+	}
+
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "services"})
+	waitMessage(t, conn, "a services snapshot", is("services", "snapshot"))
+	s.call("PATCH", "/api/v1/service/backup.service/restart", nil, 200, nil)
+	msg = waitMessage(t, conn, "a services diff", is("services", "diff"))
+	if d := as[system.ServiceDiff](t, msg.Data); len(d.Changed) != 1 || d.Changed[0].Name != "backup.service" || d.Changed[0].Status != "running" {
+		t.Fatalf("services diff = %+v", d)
+	}
+}
+
+func TestAlertsTopic(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) { c.Hub.Alerts = config.HubAlertsConfig{CPUPercent: 90} })
+	conn := s.dial("/api/v1/ws")
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "alerts"})
+	msg := waitMessage(t, conn, "the alerts", is("alerts", "snapshot"))
+	if alerts := as[[]hub.Alert](t, msg.Data); len(alerts) != 1 || alerts[0].Kind != "service_failed" || alerts[0].Host != "test" {
+		t.Fatalf("alerts = %+v, want the failed backup", alerts)
+	}
+	s.sys.PushMetrics(system.Metrics{CPU_Usage: 95})
+	msg = waitMessage(t, conn, "the cpu alert", is("alerts", "snapshot"))
+	if alerts := as[[]hub.Alert](t, msg.Data); len(alerts) != 2 || alerts[0].Kind != "cpu" {
+		t.Fatalf("alerts = %+v, want the cpu usage and the failed backup", alerts)
+	}
+}
+
+func TestLogsTopic(t *testing.T) {
+	s := newTestServer(t, nil)
+	conn := s.dial("/api/v1/ws")
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "logs", Service: "nginx", Lines: 1})
+	msg := waitMessage(t, conn, "the last line", is("logs", "data"))
+	if data, _ := msg.Data.(string); !strings.HasSuffix(data, "nginx: GET /\n") {
+		t.Fatalf("logs = %q", data)
+	}
+	s.sys.Log(fake.LogEntry{Time: time.Unix(2400, 0), Service: "nginx.service", Message: "GET /about"})
+	msg = waitMessage(t, conn, "the new line", is("logs", "data"))
+	if data, _ := msg.Data.(string); !strings.HasSuffix(data, "nginx: GET /about\n") {
+		t.Fatalf("logs = %q", data)
+	}
+	send(t, conn, wsRequest{Type: "unsubscribe", Topic: "logs"})
+	eventually(t, "the log to be closed", func() bool { return s.sys.Following() == 0 })
+
+	conf().Features.Logs = false
+	conn = s.dial("/api/v1/ws")
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "logs"})
+	msg = waitMessage(t, conn, "an error", is("logs", "error"))
+	if !strings.Contains(msg.Error, "forbidden") {
+		t.Fatalf("error = %q", msg.Error)
+	}
+}
+
+func TestTopicErrors(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) { c.CORS.AllowOrigins = []string{"https://dashboard.example"} })
+	if _, resp, err := s.tryDial("/api/v1/ws", "Origin", "https://evil.example"); err == nil || resp == nil || resp.StatusCode != 403 {
+		t.Fatalf("dial from another origin: %v, want 403", err)
+	}
+	conn := s.dial("/api/v1/ws", "Origin", "https://dashboard.example")
+
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "weather"})
+	if msg := waitMessage(t, conn, "an error", is("weather", "error")); !strings.Contains(msg.Error, "unknown topic") {
+		t.Fatalf("error = %q", msg.Error)
+	}
+	send(t, conn, wsRequest{Type: "publish", Topic: "metrics"})
+	if msg := waitMessage(t, conn, "an error", is("metrics", "error")); !strings.Contains(msg.Error, "unknown message type") {
+		t.Fatalf("error = %q", msg.Error)
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte("{"))
+	if msg := waitMessage(t, conn, "an error", is("", "error")); !strings.HasPrefix(msg.Error, "invalid message") {
+		t.Fatalf("error = %q", msg.Error)
+	}
+
+	s.sys.Fail("GetServices", errors.New("no systemd"))
+	send(t, conn, wsRequest{Type: "subscribe", Topic: "services"})
+	if msg := waitMessage(t, conn, "an error", is("services", "error")); msg.Error != "no systemd" {
+		t.Fatalf("error = %q", msg.Error)
+	}
+}
+
+func TestWebsocketsCloseOnShutdown(t *testing.T) {
+	oldShuttingDown, oldBeginShutdown := shuttingDown, beginShutdown
+	shuttingDown, beginShutdown = context.WithCancel(context.Background())
+	t.Cleanup(func() { shuttingDown, beginShutdown = oldShuttingDown, oldBeginShutdown })
+
+	s := newTestServer(t, nil)
+	info := s.dial("/api/v1/info/ws")
+	topics := s.dial("/api/v1/ws")
+	send(t, topics, wsRequest{Type: "subscribe", Topic: "logs"})
+	waitMessage(t, topics, "the logs", is("logs", "data"))
+	beginShutdown()
+	for _, conn := range []*websocket.Conn{info, topics} {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			_, _, err := conn.ReadMessage()
+			if websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("read: %v, want close 1001", err)
+			}
+		}
+	}
+	eventually(t, "the log to be closed", func() bool { return s.sys.Following() == 0 })
+}
@@ -0,0 +1,20 @@
+// Package web holds the frontend build embedded in the binary. Run `npm run build:embed` in
+// the frontend directory before `go build` to include it, otherwise only the api is served.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Dist is the frontend build, with index.html at its root.
+var Dist, _ = fs.Sub(dist, "dist")
+
+// Built reports whether the frontend was built into the binary.
+func Built() bool {
+	_, err := fs.Stat(Dist, "index.html")
+	return err == nil
+}